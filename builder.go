@@ -1,7 +1,10 @@
 package toolset
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/jonwraymond/tooladapter"
 )
@@ -19,12 +22,32 @@ type Registry interface {
 
 // Builder constructs Toolsets with filtering.
 type Builder struct {
-	name      string
-	source    []*tooladapter.CanonicalTool
-	sourceSet bool // tracks whether FromTools was called (even with nil)
-	registry  Registry
-	filters   []FilterFunc
-	policy    Policy
+	name     string
+	sources  []builderSource
+	filters  []builderFilter
+	policies []Policy
+	err      error // first configuration error, returned by Build
+}
+
+// builderFilter is a filter stage together with the label used in reports.
+type builderFilter struct {
+	kind string
+	args []string
+	fn   FilterFunc
+}
+
+// label renders the filter for build reports, e.g. "namespace(github,jira)".
+func (f builderFilter) label() string {
+	if len(f.args) == 0 {
+		return f.kind
+	}
+	return f.kind + "(" + strings.Join(f.args, ",") + ")"
+}
+
+// addFilter appends a labelled filter stage.
+func (b *Builder) addFilter(kind string, args []string, fn FilterFunc) *Builder {
+	b.filters = append(b.filters, builderFilter{kind: kind, args: args, fn: fn})
+	return b
 }
 
 // NewBuilder creates a new Builder with the given toolset name.
@@ -32,105 +55,262 @@ func NewBuilder(name string) *Builder {
 	return &Builder{name: name}
 }
 
-// FromTools sets tools as the source.
+// FromTools sets tools as the source, replacing any previous FromTools call.
+// In build reports the source is labelled "tools".
 func (b *Builder) FromTools(tools []*tooladapter.CanonicalTool) *Builder {
-	b.source = tools
-	b.sourceSet = true
-	return b
+	static := staticRegistry(tools)
+	return b.setAnonymousSource("tools", AdaptRegistry(static), static)
 }
 
-// FromRegistry sets a registry as the source.
+// FromRegistry sets a registry as the source, replacing any previous
+// FromRegistry call; FromRegistry(nil) removes it. In build reports the
+// source is labelled "registry".
+//
+// FromTools and FromRegistry may be combined; both sources then contribute
+// at priority 0, with duplicate IDs resolved as described for AddSource.
 func (b *Builder) FromRegistry(r Registry) *Builder {
-	b.registry = r
+	if r == nil {
+		return b.clearAnonymousSource("registry")
+	}
+	return b.setAnonymousSource("registry", AdaptRegistry(r), r)
+}
+
+// AddSource adds a labelled registry with a priority.
+//
+// When several sources provide the same tool ID, the source with the higher
+// priority wins; among equal priorities the source added first wins. Within
+// a single source, the last tool with a given ID wins. Labels must be unique;
+// a duplicate label is reported by Build.
+func (b *Builder) AddSource(label string, priority int, r Registry) *Builder {
+	if r == nil {
+		return b.fail(fmt.Errorf("source %q: registry is nil", label))
+	}
+	return b.addSource(label, priority, AdaptRegistry(r), r)
+}
+
+// addSource appends a labelled source. origin is the registry as supplied
+// by the caller, used to detect watchable sources.
+func (b *Builder) addSource(label string, priority int, r ContextRegistry, origin any) *Builder {
+	for _, src := range b.sources {
+		if src.label == label {
+			return b.fail(fmt.Errorf("source %q: duplicate label", label))
+		}
+	}
+	b.sources = append(b.sources, builderSource{label: label, priority: priority, registry: r, origin: origin})
+	return b
+}
+
+// AddTools adds a labelled static tool slice with a priority (see AddSource).
+func (b *Builder) AddTools(label string, priority int, tools []*tooladapter.CanonicalTool) *Builder {
+	return b.AddSource(label, priority, staticRegistry(tools))
+}
+
+// setAnonymousSource sets or replaces the source installed by FromTools or
+// FromRegistry.
+func (b *Builder) setAnonymousSource(label string, r ContextRegistry, origin any) *Builder {
+	for i, src := range b.sources {
+		if src.anonymous && src.label == label {
+			b.sources[i].registry = r
+			b.sources[i].origin = origin
+			return b
+		}
+	}
+	b.sources = append(b.sources, builderSource{label: label, registry: r, origin: origin, anonymous: true})
+	return b
+}
+
+// clearAnonymousSource removes the unlabelled source set under label.
+func (b *Builder) clearAnonymousSource(label string) *Builder {
+	for i, src := range b.sources {
+		if src.anonymous && src.label == label {
+			b.sources = append(b.sources[:i:i], b.sources[i+1:]...)
+			break
+		}
+	}
+	return b
+}
+
+// fail records the first configuration error.
+func (b *Builder) fail(err error) *Builder {
+	if b.err == nil {
+		b.err = err
+	}
 	return b
 }
 
 // WithNamespace filters to a single namespace.
 func (b *Builder) WithNamespace(ns string) *Builder {
-	b.filters = append(b.filters, NamespaceFilter(ns))
-	return b
+	return b.addFilter("namespace", []string{ns}, NamespaceFilter(ns))
 }
 
 // WithNamespaces filters to multiple namespaces.
 func (b *Builder) WithNamespaces(ns []string) *Builder {
-	b.filters = append(b.filters, NamespaceFilter(ns...))
-	return b
+	return b.addFilter("namespace", ns, NamespaceFilter(ns...))
+}
+
+// ExcludeNamespaces excludes tools in any of the namespaces.
+func (b *Builder) ExcludeNamespaces(ns []string) *Builder {
+	return b.addFilter("exclude-namespace", ns, Not(NamespaceFilter(ns...)))
+}
+
+// WithAnyTags filters to tools with ANY of the specified tags.
+func (b *Builder) WithAnyTags(tags []string) *Builder {
+	return b.addFilter("tags-any", tags, TagsAny(tags...))
+}
+
+// ExcludeTags excludes tools with ANY of the specified tags.
+func (b *Builder) ExcludeTags(tags []string) *Builder {
+	return b.addFilter("tags-none", tags, TagsNone(tags...))
 }
 
 // WithTags filters to tools with ALL specified tags.
 func (b *Builder) WithTags(tags []string) *Builder {
-	b.filters = append(b.filters, TagsAll(tags...))
-	return b
+	return b.addFilter("tags-all", tags, TagsAll(tags...))
 }
 
 // WithCategories filters to tools with ANY category.
 func (b *Builder) WithCategories(categories []string) *Builder {
-	b.filters = append(b.filters, CategoryFilter(categories...))
-	return b
+	return b.addFilter("category", categories, CategoryFilter(categories...))
 }
 
 // WithTools includes only listed tool IDs.
 func (b *Builder) WithTools(ids []string) *Builder {
-	b.filters = append(b.filters, AllowIDs(ids...))
-	return b
+	return b.addFilter("allow-ids", ids, AllowIDs(ids...))
 }
 
 // ExcludeTools excludes listed tool IDs.
 func (b *Builder) ExcludeTools(ids []string) *Builder {
-	b.filters = append(b.filters, DenyIDs(ids...))
-	return b
+	return b.addFilter("deny-ids", ids, DenyIDs(ids...))
 }
 
 // WithFilter adds a custom filter.
 func (b *Builder) WithFilter(fn FilterFunc) *Builder {
-	b.filters = append(b.filters, fn)
-	return b
+	return b.addFilter("filter", nil, fn)
 }
 
-// WithPolicy sets the access control policy (applied after filters).
+// WithExpression adds a filter compiled from a filter expression
+// (see ParseExpression). Parse errors are returned by Build.
+func (b *Builder) WithExpression(expr string) *Builder {
+	e, err := ParseExpression(expr)
+	if err != nil {
+		return b.fail(err)
+	}
+	return b.addFilter("expr", []string{expr}, e.Filter())
+}
+
+// WithAnyOf adds an OR-group: tools must match at least one of the filters.
+func (b *Builder) WithAnyOf(filters ...FilterFunc) *Builder {
+	return b.addFilter("any-of", nil, Or(filters...))
+}
+
+// WithNoneOf adds a NOR-group: tools matching any of the filters are excluded.
+func (b *Builder) WithNoneOf(filters ...FilterFunc) *Builder {
+	return b.addFilter("none-of", nil, None(filters...))
+}
+
+// WithPolicy sets the access control policy (applied after filters),
+// replacing any policies set or added before. WithPolicy(nil) removes them.
 func (b *Builder) WithPolicy(p Policy) *Builder {
-	b.policy = p
+	b.policies = nil
+	return b.AddPolicy(p)
+}
+
+// AddPolicy stacks p on top of the policies already set, for example
+// org-wide, team and per-agent layers. Stacked policies are combined with
+// DenyOverrides: any deny excludes the tool, and a tool that no policy
+// applies to is excluded. Nil policies are ignored.
+func (b *Builder) AddPolicy(p Policy) *Builder {
+	if p != nil {
+		b.policies = append(b.policies, p)
+	}
 	return b
 }
 
+// policy returns the effective policy, or nil if none is set.
+func (b *Builder) policy() Policy {
+	switch len(b.policies) {
+	case 0:
+		return nil
+	case 1:
+		return b.policies[0]
+	default:
+		return DenyOverrides(b.policies...)
+	}
+}
+
 // Build creates the Toolset.
 func (b *Builder) Build() (*Toolset, error) {
+	return b.build(context.Background(), nil)
+}
+
+// BuildWithReport creates the Toolset and a report explaining which stage
+// excluded each source tool.
+func (b *Builder) BuildWithReport() (*Toolset, *BuildReport, error) {
+	return b.BuildWithReportContext(context.Background())
+}
+
+// build runs the pipeline, recording each stage in report when non-nil.
+func (b *Builder) build(ctx context.Context, report *BuildReport) (*Toolset, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
 	// Gather source tools
-	var tools []*tooladapter.CanonicalTool
-	if b.registry != nil {
-		tools = b.registry.Tools()
-	} else if b.source != nil || b.sourceSet {
-		tools = b.source
-	} else {
-		return nil, errors.New("no source: call FromTools or FromRegistry")
+	if len(b.sources) == 0 {
+		return nil, errors.New("no source: call FromTools, FromRegistry or AddSource")
+	}
+	tools, origins, err := gatherSources(ctx, b.sources)
+	if err != nil {
+		return nil, err
+	}
+	if report != nil {
+		report.addSources(b.sources, tools, origins)
 	}
 
 	// Apply filters (AND composition)
-	for _, filter := range b.filters {
+	for i, filter := range b.filters {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		var filtered []*tooladapter.CanonicalTool
+		var dropped []*tooladapter.CanonicalTool
 		for _, t := range tools {
-			if filter(t) {
+			if t != nil && filter.fn(t) {
 				filtered = append(filtered, t)
+			} else {
+				dropped = append(dropped, t)
 			}
 		}
+		if report != nil {
+			report.addFilterStage(i, filter.label(), filtered, dropped)
+		}
 		tools = filtered
 	}
 
 	// Apply policy (last)
-	if b.policy != nil {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if p := b.policy(); p != nil {
+		policy := Explain(p)
 		var allowed []*tooladapter.CanonicalTool
+		var denied []*tooladapter.CanonicalTool
+		var decisions []Decision
 		for _, t := range tools {
-			if b.policy.Allow(t) {
+			d := policy.Decide(t)
+			if d.Allowed() {
 				allowed = append(allowed, t)
+			} else {
+				denied = append(denied, t)
+				decisions = append(decisions, d)
 			}
 		}
+		if report != nil {
+			report.addPolicyStage(allowed, denied, decisions)
+		}
 		tools = allowed
 	}
 
 	// Build toolset
-	ts := New(b.name)
-	for _, t := range tools {
-		ts.Add(t)
-	}
-	return ts, nil
+	return newFromTools(b.name, tools), nil
 }
@@ -1,6 +1,7 @@
 package toolset
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/jonwraymond/tooladapter"
@@ -82,6 +83,24 @@ func TestBuilder_FromRegistry(t *testing.T) {
 			t.Error("Tool from registry not included")
 		}
 	})
+
+	t.Run("nil removes the registry", func(t *testing.T) {
+		reg := &mockRegistry{tools: []*tooladapter.CanonicalTool{makeTool("ns", "reg", nil)}}
+		ts, err := NewBuilder("test").
+			FromTools([]*tooladapter.CanonicalTool{makeTool("ns", "static", nil)}).
+			FromRegistry(reg).
+			FromRegistry(nil).
+			Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if _, ok := ts.Get("ns:reg"); ok || ts.Count() != 1 {
+			t.Errorf("IDs() = %v, want only the static tool", ts.IDs())
+		}
+		if _, err := NewBuilder("test").FromRegistry(reg).FromRegistry(nil).Build(); err == nil {
+			t.Error("Build() should fail once the only source is removed")
+		}
+	})
 }
 
 func TestBuilder_WithNamespace(t *testing.T) {
@@ -327,3 +346,109 @@ func TestBuilder_Build_Success(t *testing.T) {
 		}
 	})
 }
+
+func TestBuilder_WithAnyOf(t *testing.T) {
+	t.Run("OR-group combined with other filters", func(t *testing.T) {
+		tools := []*tooladapter.CanonicalTool{
+			makeTool("github", "a", []string{"read"}),
+			makeTool("jira", "b", []string{"safe"}),
+			makeTool("slack", "c", []string{"safe", "write"}),
+			makeTool("slack", "d", nil),
+		}
+		ts, err := NewBuilder("test").
+			FromTools(tools).
+			WithAnyOf(NamespaceFilter("github"), TagsAny("safe")).
+			WithNoneOf(TagsAny("write")).
+			Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		ids := ts.IDs()
+		if len(ids) != 2 || ids[0] != "github:a" || ids[1] != "jira:b" {
+			t.Errorf("IDs() = %v, want [github:a jira:b]", ids)
+		}
+	})
+}
+
+func TestBuilder_AddSource(t *testing.T) {
+	withDesc := func(ns, name, desc string) *tooladapter.CanonicalTool {
+		tool := makeTool(ns, name, nil)
+		tool.Description = desc
+		return tool
+	}
+
+	t.Run("combines sources", func(t *testing.T) {
+		ts, err := NewBuilder("test").
+			AddTools("local", 0, []*tooladapter.CanonicalTool{makeTool("ns", "a", nil)}).
+			AddSource("remote", 0, &mockRegistry{tools: []*tooladapter.CanonicalTool{makeTool("ns", "b", nil)}}).
+			Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if ts.Count() != 2 {
+			t.Errorf("Count() = %d, want 2", ts.Count())
+		}
+	})
+
+	t.Run("higher priority wins", func(t *testing.T) {
+		ts, err := NewBuilder("test").
+			AddTools("low", 0, []*tooladapter.CanonicalTool{withDesc("ns", "a", "low")}).
+			AddTools("high", 10, []*tooladapter.CanonicalTool{withDesc("ns", "a", "high")}).
+			Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if got, _ := ts.Get("ns:a"); got.Description != "high" {
+			t.Errorf("Description = %q, want high", got.Description)
+		}
+	})
+
+	t.Run("equal priority keeps first source", func(t *testing.T) {
+		ts, _ := NewBuilder("test").
+			AddTools("first", 1, []*tooladapter.CanonicalTool{withDesc("ns", "a", "first")}).
+			AddTools("second", 1, []*tooladapter.CanonicalTool{withDesc("ns", "a", "second")}).
+			Build()
+		if got, _ := ts.Get("ns:a"); got.Description != "first" {
+			t.Errorf("Description = %q, want first", got.Description)
+		}
+	})
+
+	t.Run("last duplicate within a source wins", func(t *testing.T) {
+		ts, _ := NewBuilder("test").
+			AddTools("only", 0, []*tooladapter.CanonicalTool{withDesc("ns", "a", "old"), withDesc("ns", "a", "new")}).
+			Build()
+		if got, _ := ts.Get("ns:a"); got.Description != "new" {
+			t.Errorf("Description = %q, want new", got.Description)
+		}
+	})
+
+	t.Run("duplicate label is an error", func(t *testing.T) {
+		_, err := NewBuilder("test").
+			AddTools("dup", 0, nil).
+			AddTools("dup", 1, nil).
+			Build()
+		if err == nil {
+			t.Error("Build() should fail on duplicate source label")
+		}
+	})
+
+	t.Run("nil registry is an error", func(t *testing.T) {
+		if _, err := NewBuilder("test").AddSource("nil", 0, nil).Build(); err == nil {
+			t.Error("Build() should fail on nil registry")
+		}
+	})
+
+	t.Run("FromTools and FromRegistry combine", func(t *testing.T) {
+		ts, err := NewBuilder("test").
+			FromTools([]*tooladapter.CanonicalTool{makeTool("ns", "a", nil)}).
+			FromRegistry(&mockRegistry{tools: []*tooladapter.CanonicalTool{makeTool("ns", "b", nil)}}).
+			FromTools([]*tooladapter.CanonicalTool{makeTool("ns", "c", nil)}).
+			Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if !reflect.DeepEqual(ts.IDs(), []string{"ns:b", "ns:c"}) {
+			t.Errorf("IDs() = %v, want [ns:b ns:c]", ts.IDs())
+		}
+	})
+}
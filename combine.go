@@ -0,0 +1,241 @@
+package toolset
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// Combining algorithms. Every combinator returns a Policy that also
+// implements DecisionPolicy and ContextPolicy: the caller is passed through
+// to sub-policies that implement ContextPolicy.
+//
+// The deciding sub-policy is reported in Decision.Rule as a path such as
+// "deny-overrides[1]/deny-tags", where 1 is the sub-policy's index.
+
+// AllOf allows a tool only if every policy allows it. The first policy that
+// does not allow decides, and a not-applicable result counts as a deny.
+// AllOf() allows every tool.
+func AllOf(policies ...Policy) Policy {
+	return &combinedPolicy{name: "all-of", policies: policies, combine: combineAllOf}
+}
+
+// AnyOf allows a tool if any policy allows it; the first allowing policy
+// decides. Otherwise the first deny decides. AnyOf() denies every tool.
+func AnyOf(policies ...Policy) Policy {
+	return &combinedPolicy{name: "any-of", policies: policies, combine: combineAnyOf}
+}
+
+// NotPolicy inverts allow and deny. Not-applicable results are kept.
+// If p is nil or wraps a nil policy anywhere (for example PolicyFunc(nil)
+// or AllOf(nil)), NotPolicy denies every tool instead of negating the
+// resulting deny.
+func NotPolicy(p Policy) Policy {
+	combine := combineNot
+	if missingPolicy(p) {
+		combine = combineMissing
+	}
+	return &combinedPolicy{name: "not", policies: []Policy{p}, combine: combine}
+}
+
+// FirstApplicable returns the decision of the first policy that is
+// applicable to the tool (see Applies), or not-applicable if none is.
+func FirstApplicable(policies ...Policy) Policy {
+	return &combinedPolicy{name: "first-applicable", policies: policies, combine: combineFirstApplicable}
+}
+
+// DenyOverrides denies if any policy denies, else allows if any policy
+// allows, else is not-applicable. Not-applicable results are ignored.
+func DenyOverrides(policies ...Policy) Policy {
+	return &combinedPolicy{name: "deny-overrides", policies: policies, combine: combineOverrides(EffectDeny)}
+}
+
+// PermitOverrides allows if any policy allows, else denies if any policy
+// denies, else is not-applicable. Not-applicable results are ignored.
+func PermitOverrides(policies ...Policy) Policy {
+	return &combinedPolicy{name: "permit-overrides", policies: policies, combine: combineOverrides(EffectAllow)}
+}
+
+// Applies scopes p to the tools matching filter. For other tools the
+// decision is not-applicable, which the overrides and first-applicable
+// algorithms skip.
+func Applies(filter FilterFunc, p Policy) Policy {
+	return &combinedPolicy{name: "applies", policies: []Policy{p}, filter: filter, combine: combineSingle}
+}
+
+// NamedPolicy labels p so that decisions read "name/rule", e.g. "org/deny-tags".
+func NamedPolicy(name string, p Policy) Policy {
+	return &combinedPolicy{name: name, policies: []Policy{p}, combine: combineSingle}
+}
+
+// combinedPolicy evaluates sub-policies and combines their decisions.
+type combinedPolicy struct {
+	name     string
+	policies []Policy
+	filter   FilterFunc // optional applicability filter
+	combine  func(next func(i int) Decision, n int) (Decision, int)
+}
+
+// Allow implements Policy.
+func (c *combinedPolicy) Allow(t *tooladapter.CanonicalTool) bool {
+	return c.Decide(t).Allowed()
+}
+
+// Decide implements DecisionPolicy. Sub-policies are evaluated as by
+// Explain, without a caller.
+func (c *combinedPolicy) Decide(t *tooladapter.CanonicalTool) Decision {
+	return c.decide(t, func(p Policy) Decision { return Explain(p).Decide(t) })
+}
+
+// AllowContext implements ContextPolicy.
+func (c *combinedPolicy) AllowContext(ctx context.Context, caller *Principal, t *tooladapter.CanonicalTool) bool {
+	return c.decideContext(ctx, caller, t).Allowed()
+}
+
+// decideContext is like Decide, evaluating sub-policies for caller.
+func (c *combinedPolicy) decideContext(ctx context.Context, caller *Principal, t *tooladapter.CanonicalTool) Decision {
+	return c.decide(t, func(p Policy) Decision { return decideFor(ctx, caller, p, t) })
+}
+
+// decide combines the sub-decisions returned by eval. Sub-policies are
+// evaluated lazily, so algorithms stop once the outcome is known.
+func (c *combinedPolicy) decide(t *tooladapter.CanonicalTool, eval func(Policy) Decision) Decision {
+	if t == nil {
+		return nilToolDecision
+	}
+	if c.filter != nil && !c.filter(t) {
+		return Decision{Effect: EffectNotApplicable, Rule: c.name, Reason: "tool does not match"}
+	}
+	d, i := c.combine(func(i int) Decision { return eval(c.policies[i]) }, len(c.policies))
+	switch {
+	case i < 0:
+		d.Rule = c.name
+	case len(c.policies) == 1:
+		d.Rule = c.name + "/" + d.Rule
+	default:
+		d.Rule = c.name + "[" + strconv.Itoa(i) + "]/" + d.Rule
+	}
+	return d
+}
+
+// missingPolicy reports whether p is nil, a nil function policy, or a
+// combinator over such a policy.
+func missingPolicy(p Policy) bool {
+	switch p := p.(type) {
+	case nil:
+		return true
+	case PolicyFunc:
+		return p == nil
+	case DecisionFunc:
+		return p == nil
+	case ContextPolicyFunc:
+		return p == nil
+	case *combinedPolicy:
+		if p == nil {
+			return true
+		}
+		for _, sub := range p.policies {
+			if missingPolicy(sub) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contextDecider is implemented by policies that explain decisions for a caller.
+type contextDecider interface {
+	decideContext(ctx context.Context, p *Principal, t *tooladapter.CanonicalTool) Decision
+}
+
+// decideFor returns p's decision for a caller, using the most specific
+// interface p implements.
+func decideFor(ctx context.Context, caller *Principal, p Policy, t *tooladapter.CanonicalTool) Decision {
+	switch p := p.(type) {
+	case contextDecider:
+		return p.decideContext(ctx, caller, t)
+	case ContextPolicy:
+		if p.AllowContext(ctx, caller, t) {
+			return Decision{Effect: EffectAllow, Rule: "policy", Reason: "allowed by policy"}
+		}
+		return Decision{Effect: EffectDeny, Rule: "policy", Reason: "denied by policy"}
+	}
+	return Explain(p).Decide(t)
+}
+
+// The combine functions return the deciding decision and the index of the
+// deciding sub-policy, or -1 if no sub-policy decided.
+
+func combineAllOf(next func(int) Decision, n int) (Decision, int) {
+	for i := 0; i < n; i++ {
+		if d := next(i); !d.Allowed() {
+			d.Effect = EffectDeny
+			return d, i
+		}
+	}
+	return Decision{Effect: EffectAllow, Reason: "all policies allow the tool"}, -1
+}
+
+func combineAnyOf(next func(int) Decision, n int) (Decision, int) {
+	first, firstIndex := Decision{Effect: EffectDeny, Reason: "no policy allows the tool"}, -1
+	for i := 0; i < n; i++ {
+		d := next(i)
+		if d.Allowed() {
+			return d, i
+		}
+		if firstIndex < 0 && d.Effect == EffectDeny {
+			first, firstIndex = d, i
+		}
+	}
+	return first, firstIndex
+}
+
+func combineNot(next func(int) Decision, _ int) (Decision, int) {
+	d := next(0)
+	switch d.Effect {
+	case EffectAllow:
+		d.Effect = EffectDeny
+		d.Reason = "negated: " + d.Reason
+	case EffectDeny:
+		d.Effect = EffectAllow
+		d.Reason = "negated: " + d.Reason
+	}
+	return d, 0
+}
+
+// combineMissing fails closed for a NotPolicy over a missing policy.
+func combineMissing(func(int) Decision, int) (Decision, int) {
+	return nilPolicyDecision, 0
+}
+
+func combineFirstApplicable(next func(int) Decision, n int) (Decision, int) {
+	for i := 0; i < n; i++ {
+		if d := next(i); d.Effect != EffectNotApplicable {
+			return d, i
+		}
+	}
+	return Decision{Effect: EffectNotApplicable, Reason: "no policy applies"}, -1
+}
+
+func combineSingle(next func(int) Decision, _ int) (Decision, int) {
+	return next(0), 0
+}
+
+// combineOverrides returns an algorithm where the first decision with the
+// overriding effect wins, then the first decision with the other effect.
+func combineOverrides(override Effect) func(func(int) Decision, int) (Decision, int) {
+	return func(next func(int) Decision, n int) (Decision, int) {
+		fallback, fallbackIndex := Decision{Effect: EffectNotApplicable, Reason: "no policy applies"}, -1
+		for i := 0; i < n; i++ {
+			d := next(i)
+			switch {
+			case d.Effect == override:
+				return d, i
+			case d.Effect != EffectNotApplicable && fallbackIndex < 0:
+				fallback, fallbackIndex = d, i
+			}
+		}
+		return fallback, fallbackIndex
+	}
+}
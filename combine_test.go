@@ -0,0 +1,157 @@
+package toolset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func TestPolicyCombinators(t *testing.T) {
+	ghRead := makeTool("github", "read", []string{"read"})
+	ghWrite := makeTool("github", "write", []string{"write"})
+	slack := makeTool("slack", "send", []string{"write"})
+
+	denyWrite := DenyTags("write")
+	github := AllowNamespaces("github")
+	onlySlack := Applies(NamespaceFilter("slack"), AllowAll())
+
+	tests := []struct {
+		name   string
+		policy Policy
+		tool   *tooladapter.CanonicalTool
+		effect Effect
+		rule   string
+	}{
+		{"all-of allows", AllOf(github, denyWrite), ghRead, EffectAllow, "all-of"},
+		{"all-of first deny decides", AllOf(github, denyWrite), ghWrite, EffectDeny, "all-of[1]/deny-tags"},
+		{"all-of empty allows", AllOf(), ghRead, EffectAllow, "all-of"},
+		{"all-of not-applicable denies", AllOf(onlySlack), ghRead, EffectDeny, "all-of/applies"},
+		{"any-of first allow decides", AnyOf(denyWrite, github), ghWrite, EffectAllow, "any-of[1]/allow-namespaces"},
+		{"any-of first deny decides", AnyOf(github, denyWrite), slack, EffectDeny, "any-of[0]/allow-namespaces"},
+		{"any-of empty denies", AnyOf(), ghRead, EffectDeny, "any-of"},
+		{"not inverts", NotPolicy(github), slack, EffectAllow, "not/allow-namespaces"},
+		{"not keeps nil deny", NotPolicy(nil), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps nil func deny", NotPolicy(PolicyFunc(nil)), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps nil decision func deny", NotPolicy(DecisionFunc(nil)), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps nil context func deny", NotPolicy(ContextPolicyFunc(nil)), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps named nil deny", NotPolicy(NamedPolicy("org", nil)), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps all-of nil deny", NotPolicy(AllOf(nil)), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps applies nil deny", NotPolicy(Applies(NamespaceFilter("github"), nil)), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps nested nil deny", NotPolicy(AnyOf(github, NotPolicy(nil))), ghRead, EffectDeny, "not/nil-policy"},
+		{"not keeps not-applicable", NotPolicy(onlySlack), ghRead, EffectNotApplicable, "not/applies"},
+		{"applies", onlySlack, slack, EffectAllow, "applies/allow-all"},
+		{"first-applicable skips", FirstApplicable(onlySlack, denyWrite), ghWrite, EffectDeny, "first-applicable[1]/deny-tags"},
+		{"first-applicable none", FirstApplicable(onlySlack), ghRead, EffectNotApplicable, "first-applicable"},
+		{"deny-overrides", DenyOverrides(github, denyWrite), ghWrite, EffectDeny, "deny-overrides[1]/deny-tags"},
+		{"deny-overrides allows", DenyOverrides(onlySlack, github), ghRead, EffectAllow, "deny-overrides[1]/allow-namespaces"},
+		{"deny-overrides none applies", DenyOverrides(onlySlack), ghRead, EffectNotApplicable, "deny-overrides"},
+		{"permit-overrides", PermitOverrides(denyWrite, github), ghWrite, EffectAllow, "permit-overrides[1]/allow-namespaces"},
+		{"permit-overrides denies", PermitOverrides(denyWrite, github), slack, EffectDeny, "permit-overrides[0]/deny-tags"},
+		{"named", NamedPolicy("org", denyWrite), ghWrite, EffectDeny, "org/deny-tags"},
+		{"nested", DenyOverrides(NamedPolicy("org", github), NamedPolicy("team", denyWrite)), slack, EffectDeny, "deny-overrides[0]/org/allow-namespaces"},
+		{"plain policy", AllOf(PolicyFunc(func(*tooladapter.CanonicalTool) bool { return false })), ghRead, EffectDeny, "all-of/policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Explain(tt.policy).Decide(tt.tool)
+			if d.Effect != tt.effect || d.Rule != tt.rule {
+				t.Errorf("Decide() = %v (rule %q), want %v by %q", d, d.Rule, tt.effect, tt.rule)
+			}
+			if tt.policy.Allow(tt.tool) != (tt.effect == EffectAllow) {
+				t.Errorf("Allow() disagrees with Decide()")
+			}
+		})
+	}
+
+	t.Run("nil tool", func(t *testing.T) {
+		if AllOf().Allow(nil) {
+			t.Error("Allow(nil) = true, want false")
+		}
+	})
+
+	t.Run("stops at the deciding policy", func(t *testing.T) {
+		calls := 0
+		counting := PolicyFunc(func(*tooladapter.CanonicalTool) bool { calls++; return true })
+		DenyOverrides(DenyAll(), counting).Allow(ghRead)
+		if calls != 0 {
+			t.Errorf("later policy evaluated %d times, want 0", calls)
+		}
+	})
+
+	t.Run("passes the caller to sub-policies", func(t *testing.T) {
+		p := AllOf(AllowAll(), AllowGrantedScopes()).(ContextPolicy)
+		tool := scopedTool("repo", "repo:read")
+		if p.AllowContext(context.Background(), nil, tool) {
+			t.Error("anonymous caller should be denied")
+		}
+		if !p.AllowContext(context.Background(), &Principal{Scopes: []string{"repo:read"}}, tool) {
+			t.Error("caller with the scope should be allowed")
+		}
+	})
+
+	t.Run("negated missing policy denies callers", func(t *testing.T) {
+		p := NotPolicy(NamedPolicy("org", ContextPolicyFunc(nil))).(ContextPolicy)
+		if p.AllowContext(context.Background(), &Principal{Subject: "u"}, ghRead) {
+			t.Error("AllowContext() = true, want false")
+		}
+	})
+}
+
+func TestBuilder_AddPolicy(t *testing.T) {
+	tools := []*tooladapter.CanonicalTool{
+		makeTool("github", "read", []string{"read"}),
+		makeTool("github", "delete", []string{"dangerous"}),
+		makeTool("slack", "send", nil),
+	}
+
+	t.Run("stacks policies with deny-overrides", func(t *testing.T) {
+		ts, report, err := NewBuilder("layered").
+			FromTools(tools).
+			AddPolicy(NamedPolicy("org", DenyTags("dangerous"))).
+			AddPolicy(NamedPolicy("team", AllowNamespaces("github"))).
+			BuildWithReport()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if !reflect.DeepEqual(ts.IDs(), []string{"github:read"}) {
+			t.Errorf("IDs() = %v", ts.IDs())
+		}
+		rules := map[string]string{}
+		for _, tr := range report.Tools {
+			if tr.Decision != nil {
+				rules[tr.ID] = tr.Decision.Rule
+			}
+		}
+		want := map[string]string{
+			"github:delete": "deny-overrides[0]/org/deny-tags",
+			"slack:send":    "deny-overrides[1]/team/allow-namespaces",
+		}
+		if !reflect.DeepEqual(rules, want) {
+			t.Errorf("decision rules = %v, want %v", rules, want)
+		}
+	})
+
+	t.Run("WithPolicy replaces stacked policies", func(t *testing.T) {
+		ts, err := NewBuilder("t").
+			FromTools(tools).
+			AddPolicy(DenyAll()).
+			AddPolicy(DenyAll()).
+			WithPolicy(AllowAll()).
+			Build()
+		if err != nil || ts.Count() != 3 {
+			t.Errorf("Build() = %d tools, %v; want 3", ts.Count(), err)
+		}
+	})
+
+	t.Run("tools no policy applies to are excluded", func(t *testing.T) {
+		ts, _ := NewBuilder("t").
+			FromTools(tools).
+			AddPolicy(Applies(NamespaceFilter("slack"), AllowAll())).
+			Build()
+		if !reflect.DeepEqual(ts.IDs(), []string{"slack:send"}) {
+			t.Errorf("IDs() = %v", ts.IDs())
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package toolset
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// ContextRegistry provides tools for the builder with cancellation and
+// error reporting.
+//
+// Contract:
+// - Concurrency: Tools may be called concurrently; implementations must be safe or document otherwise.
+// - Context: implementations should return promptly with ctx.Err() once ctx is done.
+// - Errors: a non-nil error fails the build; it is never treated as an empty source.
+// - Ownership: returned slice is caller-owned; tools are shared and read-only.
+// - Determinism: ordering should be deterministic for identical registry state.
+// - Nil handling: returning (nil, nil) is treated as empty.
+type ContextRegistry interface {
+	Tools(ctx context.Context) ([]*tooladapter.CanonicalTool, error)
+}
+
+// AdaptRegistry returns r as a ContextRegistry. The adapter checks ctx
+// before calling r.Tools, which itself cannot be interrupted or fail.
+// AdaptRegistry(nil) returns nil.
+func AdaptRegistry(r Registry) ContextRegistry {
+	if r == nil {
+		return nil
+	}
+	return registryAdapter{r}
+}
+
+// registryAdapter adapts a Registry to ContextRegistry.
+type registryAdapter struct {
+	Registry
+}
+
+func (a registryAdapter) Tools(ctx context.Context) ([]*tooladapter.CanonicalTool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return a.Registry.Tools(), nil
+}
+
+// SourceError reports a Builder source that failed to provide its tools.
+type SourceError struct {
+	// Label is the source label.
+	Label string
+
+	// Err is the registry's error, or the context error.
+	Err error
+}
+
+func (e *SourceError) Error() string {
+	return "source " + e.Label + ": " + e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// FromContextRegistry is like FromRegistry for a ContextRegistry. Both
+// methods set the same unlabelled "registry" source, and nil removes it.
+func (b *Builder) FromContextRegistry(r ContextRegistry) *Builder {
+	if r == nil {
+		return b.clearAnonymousSource("registry")
+	}
+	return b.setAnonymousSource("registry", r, r)
+}
+
+// AddContextSource is like AddSource for a ContextRegistry.
+func (b *Builder) AddContextSource(label string, priority int, r ContextRegistry) *Builder {
+	if r == nil {
+		return b.fail(fmt.Errorf("source %q: registry is nil", label))
+	}
+	return b.addSource(label, priority, r, r)
+}
+
+// BuildContext is like Build but passes ctx to every source and stops with
+// ctx's error once it is done. Source failures are returned as *SourceError.
+func (b *Builder) BuildContext(ctx context.Context) (*Toolset, error) {
+	return b.build(ctx, nil)
+}
+
+// BuildWithReportContext is like BuildWithReport with the context handling
+// of BuildContext.
+func (b *Builder) BuildWithReportContext(ctx context.Context) (*Toolset, *BuildReport, error) {
+	report := &BuildReport{}
+	ts, err := b.build(ctx, report)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.finish()
+	return ts, report, nil
+}
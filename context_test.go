@@ -0,0 +1,111 @@
+package toolset
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// ctxRegistry is a ContextRegistry fake.
+type ctxRegistry struct {
+	tools []*tooladapter.CanonicalTool
+	err   error
+	block bool // wait for ctx to be done
+}
+
+func (r *ctxRegistry) Tools(ctx context.Context) ([]*tooladapter.CanonicalTool, error) {
+	if r.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return r.tools, r.err
+}
+
+func TestAdaptRegistry(t *testing.T) {
+	t.Run("returns registry tools", func(t *testing.T) {
+		r := AdaptRegistry(&mockRegistry{tools: []*tooladapter.CanonicalTool{makeTool("ns", "a", nil)}})
+		tools, err := r.Tools(context.Background())
+		if err != nil || len(tools) != 1 {
+			t.Errorf("Tools() = %v, %v", tools, err)
+		}
+	})
+
+	t.Run("checks context first", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := AdaptRegistry(&mockRegistry{}).Tools(ctx); !errors.Is(err, context.Canceled) {
+			t.Errorf("Tools() error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("nil stays nil", func(t *testing.T) {
+		if AdaptRegistry(nil) != nil {
+			t.Error("AdaptRegistry(nil) should be nil")
+		}
+	})
+}
+
+func TestBuilder_BuildContext(t *testing.T) {
+	t.Run("builds from context and plain sources", func(t *testing.T) {
+		ts, err := NewBuilder("test").
+			AddContextSource("remote", 0, &ctxRegistry{tools: []*tooladapter.CanonicalTool{makeTool("ns", "a", nil)}}).
+			AddSource("local", 0, &mockRegistry{tools: []*tooladapter.CanonicalTool{makeTool("ns", "b", nil)}}).
+			BuildContext(context.Background())
+		if err != nil {
+			t.Fatalf("BuildContext() error = %v", err)
+		}
+		if ts.Count() != 2 {
+			t.Errorf("Count() = %d, want 2", ts.Count())
+		}
+	})
+
+	t.Run("propagates source errors", func(t *testing.T) {
+		boom := errors.New("backend unavailable")
+		_, err := NewBuilder("test").
+			AddTools("local", 0, []*tooladapter.CanonicalTool{makeTool("ns", "a", nil)}).
+			AddContextSource("remote", 0, &ctxRegistry{err: boom}).
+			BuildContext(context.Background())
+		var srcErr *SourceError
+		if !errors.As(err, &srcErr) || srcErr.Label != "remote" || !errors.Is(err, boom) {
+			t.Errorf("BuildContext() error = %v, want SourceError for remote", err)
+		}
+	})
+
+	t.Run("respects deadlines", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err := NewBuilder("test").
+			FromContextRegistry(&ctxRegistry{block: true}).
+			BuildContext(ctx)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("BuildContext() error = %v, want DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("cancelled context stops before sources", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, _, err := NewBuilder("test").
+			FromTools([]*tooladapter.CanonicalTool{makeTool("ns", "a", nil)}).
+			BuildWithReportContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("BuildWithReportContext() error = %v, want Canceled", err)
+		}
+	})
+
+	t.Run("Build surfaces context source errors", func(t *testing.T) {
+		_, err := NewBuilder("test").FromContextRegistry(&ctxRegistry{err: errors.New("down")}).Build()
+		if err == nil {
+			t.Error("Build() should fail when a source fails")
+		}
+	})
+
+	t.Run("nil context source is an error", func(t *testing.T) {
+		if _, err := NewBuilder("test").AddContextSource("x", 0, nil).Build(); err == nil {
+			t.Error("Build() should fail on nil registry")
+		}
+	})
+}
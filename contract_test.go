@@ -60,6 +60,34 @@ func TestPolicyContract_NilFunc(t *testing.T) {
 	}
 }
 
+func TestDecisionPolicyContract_Builtins(t *testing.T) {
+	tool := &tooladapter.CanonicalTool{
+		Namespace:      "alpha",
+		Name:           "echo",
+		Tags:           []string{"safe"},
+		RequiredScopes: []string{"read"},
+	}
+	policies := []Policy{
+		AllowAll(),
+		DenyAll(),
+		AllowNamespaces("alpha"),
+		DenyTags("danger"),
+		AllowScopes("read"),
+	}
+	for _, p := range policies {
+		dp, ok := p.(DecisionPolicy)
+		if !ok {
+			t.Fatalf("%T should implement DecisionPolicy", p)
+		}
+		if dp.Decide(nil).Allowed() {
+			t.Fatalf("%T should deny nil tool", p)
+		}
+		if d := dp.Decide(tool); d.Allowed() != dp.Allow(tool) {
+			t.Fatalf("%T: Decide and Allow disagree (%v)", p, d)
+		}
+	}
+}
+
 type stubRegistry struct {
 	tools []*tooladapter.CanonicalTool
 }
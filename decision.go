@@ -0,0 +1,127 @@
+package toolset
+
+import (
+	"fmt"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// Effect is the outcome of a policy decision.
+type Effect int
+
+const (
+	// EffectDeny excludes the tool. It is the zero value so that an
+	// uninitialized Decision fails closed.
+	EffectDeny Effect = iota
+	// EffectAllow includes the tool.
+	EffectAllow
+	// EffectNotApplicable means the policy has no opinion on the tool
+	// (see Applies). It does not allow the tool; at the top level of a
+	// build it is treated as a deny.
+	EffectNotApplicable
+)
+
+// String returns "allow", "deny" or "not-applicable".
+func (e Effect) String() string {
+	switch e {
+	case EffectAllow:
+		return "allow"
+	case EffectDeny:
+		return "deny"
+	case EffectNotApplicable:
+		return "not-applicable"
+	default:
+		return fmt.Sprintf("Effect(%d)", int(e))
+	}
+}
+
+// Decision is a policy outcome together with the reason it was reached.
+type Decision struct {
+	// Effect is the allow/deny outcome.
+	Effect Effect
+
+	// Rule names the rule that decided (e.g., "allow-namespaces").
+	Rule string
+
+	// Reason is a human-readable explanation.
+	Reason string
+
+	// Attribute is the tool attribute that caused the decision
+	// (e.g., "namespace", "tags", "scopes"). Empty if not attribute-based.
+	Attribute string
+
+	// Value is the attribute value that matched (e.g., the denied tag).
+	Value string
+}
+
+// Allowed reports whether the decision allows the tool.
+func (d Decision) Allowed() bool { return d.Effect == EffectAllow }
+
+// String returns a compact one-line description of the decision.
+func (d Decision) String() string {
+	s := d.Effect.String()
+	if d.Rule != "" {
+		s += " by " + d.Rule
+	}
+	if d.Reason != "" {
+		s += ": " + d.Reason
+	}
+	return s
+}
+
+// DecisionPolicy is a Policy that can explain its decisions.
+//
+// Contract:
+// - Concurrency: implementations must be safe for concurrent use after construction.
+// - Consistency: Decide(t).Allowed() must equal Allow(t) for every tool.
+// - Errors: implementations must encode deny via EffectDeny; no panic for invalid input.
+// - Ownership: implementations must not mutate the tool; treat it as read-only.
+// - Determinism: for a given tool, Decide returns a stable result.
+// - Nil handling: if tool is nil, Decide must return a deny decision.
+type DecisionPolicy interface {
+	Policy
+	Decide(tool *tooladapter.CanonicalTool) Decision
+}
+
+// DecisionFunc adapts a function to the DecisionPolicy interface.
+type DecisionFunc func(*tooladapter.CanonicalTool) Decision
+
+// Decide implements DecisionPolicy.
+func (f DecisionFunc) Decide(t *tooladapter.CanonicalTool) Decision {
+	if t == nil {
+		return nilToolDecision
+	}
+	if f == nil {
+		return nilPolicyDecision
+	}
+	return f(t)
+}
+
+// Allow implements Policy.
+func (f DecisionFunc) Allow(t *tooladapter.CanonicalTool) bool {
+	return f.Decide(t).Allowed()
+}
+
+var (
+	nilToolDecision   = Decision{Effect: EffectDeny, Rule: "nil-tool", Reason: "tool is nil"}
+	nilPolicyDecision = Decision{Effect: EffectDeny, Rule: "nil-policy", Reason: "policy is nil"}
+)
+
+// Explain lifts any Policy into a DecisionPolicy.
+// Policies that already implement DecisionPolicy are returned unchanged.
+// Other policies produce decisions with rule "policy" and a generic reason.
+// A nil policy denies every tool.
+func Explain(p Policy) DecisionPolicy {
+	if dp, ok := p.(DecisionPolicy); ok {
+		return dp
+	}
+	return DecisionFunc(func(t *tooladapter.CanonicalTool) Decision {
+		if p == nil {
+			return nilPolicyDecision
+		}
+		if p.Allow(t) {
+			return Decision{Effect: EffectAllow, Rule: "policy", Reason: "allowed by policy"}
+		}
+		return Decision{Effect: EffectDeny, Rule: "policy", Reason: "denied by policy"}
+	})
+}
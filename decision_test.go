@@ -0,0 +1,137 @@
+package toolset
+
+import (
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func TestEffect_String(t *testing.T) {
+	if EffectAllow.String() != "allow" {
+		t.Errorf("EffectAllow.String() = %q, want %q", EffectAllow.String(), "allow")
+	}
+	if EffectDeny.String() != "deny" {
+		t.Errorf("EffectDeny.String() = %q, want %q", EffectDeny.String(), "deny")
+	}
+	var zero Decision
+	if zero.Allowed() {
+		t.Error("zero Decision should deny")
+	}
+}
+
+func TestDecisionFunc(t *testing.T) {
+	t.Run("nil tool denied", func(t *testing.T) {
+		called := false
+		fn := DecisionFunc(func(*tooladapter.CanonicalTool) Decision {
+			called = true
+			return Decision{Effect: EffectAllow}
+		})
+		if fn.Decide(nil).Allowed() {
+			t.Error("Decide(nil) should deny")
+		}
+		if called {
+			t.Error("function should not be called for nil tool")
+		}
+	})
+
+	t.Run("nil func denies", func(t *testing.T) {
+		var fn DecisionFunc
+		if fn.Allow(makeTool("ns", "foo", nil)) {
+			t.Error("nil DecisionFunc should deny")
+		}
+	})
+
+	t.Run("Allow matches Decide", func(t *testing.T) {
+		fn := DecisionFunc(func(t *tooladapter.CanonicalTool) Decision {
+			if t.Name == "ok" {
+				return Decision{Effect: EffectAllow}
+			}
+			return Decision{Effect: EffectDeny}
+		})
+		if !fn.Allow(makeTool("ns", "ok", nil)) {
+			t.Error("Allow should follow Decide for allowed tool")
+		}
+		if fn.Allow(makeTool("ns", "no", nil)) {
+			t.Error("Allow should follow Decide for denied tool")
+		}
+	})
+}
+
+func TestExplain(t *testing.T) {
+	t.Run("lifts PolicyFunc", func(t *testing.T) {
+		dp := Explain(PolicyFunc(func(t *tooladapter.CanonicalTool) bool {
+			return t.Name == "ok"
+		}))
+
+		d := dp.Decide(makeTool("ns", "ok", nil))
+		if !d.Allowed() || d.Rule != "policy" {
+			t.Errorf("Decide(ok) = %+v, want allow by policy", d)
+		}
+		d = dp.Decide(makeTool("ns", "no", nil))
+		if d.Allowed() || d.Reason == "" {
+			t.Errorf("Decide(no) = %+v, want deny with reason", d)
+		}
+		if dp.Decide(nil).Allowed() {
+			t.Error("lifted policy should deny nil tool")
+		}
+	})
+
+	t.Run("returns DecisionPolicy unchanged", func(t *testing.T) {
+		p := DenyTags("danger")
+		dp := Explain(p)
+		d := dp.Decide(makeTool("ns", "foo", []string{"danger"}))
+		if d.Rule != "deny-tags" {
+			t.Errorf("Rule = %q, want %q", d.Rule, "deny-tags")
+		}
+	})
+
+	t.Run("nil policy denies", func(t *testing.T) {
+		dp := Explain(nil)
+		if dp.Allow(makeTool("ns", "foo", nil)) {
+			t.Error("Explain(nil) should deny")
+		}
+	})
+}
+
+func TestBuiltinDecisions(t *testing.T) {
+	t.Run("AllowNamespaces reports namespace", func(t *testing.T) {
+		d := Explain(AllowNamespaces("github")).Decide(makeTool("slack", "send", nil))
+		if d.Allowed() {
+			t.Fatal("expected deny")
+		}
+		if d.Rule != "allow-namespaces" || d.Attribute != "namespace" || d.Value != "slack" {
+			t.Errorf("decision = %+v, want allow-namespaces namespace=slack", d)
+		}
+	})
+
+	t.Run("DenyTags reports tag", func(t *testing.T) {
+		d := Explain(DenyTags("dangerous", "deprecated")).Decide(makeTool("ns", "foo", []string{"safe", "deprecated"}))
+		if d.Allowed() {
+			t.Fatal("expected deny")
+		}
+		if d.Attribute != "tags" || d.Value != "deprecated" {
+			t.Errorf("decision = %+v, want tags=deprecated", d)
+		}
+	})
+
+	t.Run("AllowScopes reports missing scope", func(t *testing.T) {
+		tool := makeTool("github", "delete", nil)
+		tool.RequiredScopes = []string{"read:repo", "admin:repo"}
+		d := Explain(AllowScopes("read:repo")).Decide(tool)
+		if d.Allowed() {
+			t.Fatal("expected deny")
+		}
+		if d.Attribute != "scopes" || d.Value != "admin:repo" {
+			t.Errorf("decision = %+v, want scopes=admin:repo", d)
+		}
+	})
+
+	t.Run("allow decisions have reasons", func(t *testing.T) {
+		for _, p := range []Policy{AllowAll(), AllowNamespaces("ns"), DenyTags("x"), AllowScopes()} {
+			d := Explain(p).Decide(makeTool("ns", "foo", nil))
+			if !d.Allowed() || d.Rule == "" || d.Reason == "" {
+				t.Errorf("decision = %+v, want allow with rule and reason", d)
+			}
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package toolset
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// FieldError describes an invalid field in a declarative document.
+type FieldError struct {
+	// Path locates the field, e.g. "tags.any[1]". Empty for the document root.
+	Path string
+
+	// Line and Column locate the field in the source (1-based).
+	// Both are zero when the error was found after decoding.
+	Line   int
+	Column int
+
+	// Msg describes the problem.
+	Msg string
+}
+
+func (e *FieldError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "document"
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("%s (line %d): %s", path, e.Line, e.Msg)
+	}
+	return path + ": " + e.Msg
+}
+
+// ValidationErrors collects every FieldError found in a document.
+type ValidationErrors []*FieldError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// err returns errs as an error, or nil if empty.
+func (errs ValidationErrors) err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// fieldPath joins a parent path and a field name.
+func fieldPath(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
+
+// indexPath appends a sequence index to a path.
+func indexPath(parent string, i int) string {
+	return parent + "[" + strconv.Itoa(i) + "]"
+}
+
+// nodeDecoder decodes YAML (and therefore JSON) documents strictly,
+// recording errors with their field paths instead of stopping at the first.
+type nodeDecoder struct {
+	errs ValidationErrors
+}
+
+// parseDocument parses data into its root mapping node.
+func parseDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &FieldError{Msg: err.Error()}
+	}
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return nil, &FieldError{Msg: "document is empty"}
+	}
+	return doc.Content[0], nil
+}
+
+func (d *nodeDecoder) errorf(n *yaml.Node, path, format string, args ...any) {
+	e := &FieldError{Path: path, Msg: fmt.Sprintf(format, args...)}
+	if n != nil {
+		e.Line, e.Column = n.Line, n.Column
+	}
+	d.errs = append(d.errs, e)
+}
+
+// resolve follows YAML aliases.
+func resolve(n *yaml.Node) *yaml.Node {
+	for n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// isNull reports whether n is absent or an explicit null.
+func isNull(n *yaml.Node) bool {
+	n = resolve(n)
+	return n == nil || n.Kind == yaml.ScalarNode && n.Tag == "!!null"
+}
+
+// mapping decodes a mapping node, dispatching each key to its field decoder.
+// Unknown and duplicate keys are reported as errors.
+func (d *nodeDecoder) mapping(n *yaml.Node, path string, fields map[string]func(*yaml.Node, string)) {
+	d.entries(n, path, func(key, value *yaml.Node, keyPath string) {
+		decode, ok := fields[key.Value]
+		if !ok {
+			d.errorf(key, keyPath, "unknown field")
+			return
+		}
+		decode(value, keyPath)
+	})
+}
+
+// entries decodes a mapping node with arbitrary keys, calling fn for each
+// entry in document order. Duplicate keys are reported as errors.
+func (d *nodeDecoder) entries(n *yaml.Node, path string, fn func(key, value *yaml.Node, keyPath string)) {
+	n = resolve(n)
+	if isNull(n) {
+		return
+	}
+	if n.Kind != yaml.MappingNode {
+		d.errorf(n, path, "must be a mapping")
+		return
+	}
+	seen := make(map[string]bool, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		keyPath := fieldPath(path, key.Value)
+		if seen[key.Value] {
+			d.errorf(key, keyPath, "duplicate field")
+			continue
+		}
+		seen[key.Value] = true
+		fn(key, value, keyPath)
+	}
+}
+
+// str decodes a string scalar.
+func (d *nodeDecoder) str(n *yaml.Node, path string) string {
+	n = resolve(n)
+	if isNull(n) {
+		return ""
+	}
+	if n.Kind != yaml.ScalarNode || n.Tag != "!!str" {
+		d.errorf(n, path, "must be a string")
+		return ""
+	}
+	return n.Value
+}
+
+// strs decodes a sequence of non-empty strings.
+func (d *nodeDecoder) strs(n *yaml.Node, path string) []string {
+	n = resolve(n)
+	if isNull(n) {
+		return nil
+	}
+	if n.Kind != yaml.SequenceNode {
+		d.errorf(n, path, "must be a list of strings")
+		return nil
+	}
+	out := make([]string, 0, len(n.Content))
+	for i, item := range n.Content {
+		item = resolve(item)
+		itemPath := indexPath(path, i)
+		switch {
+		case item.Kind != yaml.ScalarNode || item.Tag != "!!str":
+			d.errorf(item, itemPath, "must be a string")
+		case item.Value == "":
+			d.errorf(item, itemPath, "must not be empty")
+		default:
+			out = append(out, item.Value)
+		}
+	}
+	return out
+}
+
+// checkStrings reports empty and duplicate entries in a decoded list.
+func checkStrings(errs *ValidationErrors, path string, values []string) {
+	seen := make(map[string]bool, len(values))
+	for i, v := range values {
+		switch {
+		case v == "":
+			*errs = append(*errs, &FieldError{Path: indexPath(path, i), Msg: "must not be empty"})
+		case seen[v]:
+			*errs = append(*errs, &FieldError{Path: indexPath(path, i), Msg: "duplicate value " + strconv.Quote(v)})
+		}
+		seen[v] = true
+	}
+}
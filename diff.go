@@ -0,0 +1,259 @@
+package toolset
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// ToolsetDiff reports the differences between two toolsets.
+// All slices are sorted by tool ID and are never nil, so the JSON encoding
+// is stable.
+type ToolsetDiff struct {
+	// From and To are the names of the compared toolsets.
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Added lists IDs present only in the second toolset.
+	Added []string `json:"added"`
+
+	// Removed lists IDs present only in the first toolset.
+	Removed []string `json:"removed"`
+
+	// Modified lists tools present in both with differing fields.
+	Modified []ToolDiff `json:"modified"`
+}
+
+// ToolDiff lists the field changes of a single tool.
+type ToolDiff struct {
+	ID      string        `json:"id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is a single changed field.
+//
+// For schema fields, Path is a JSON pointer into the schema (for example
+// "/properties/query/type") and a nil Old or New means the keyword was added
+// or removed.
+type FieldChange struct {
+	// Field is one of "description", "tags", "category", "scopes",
+	// "inputSchema" or "outputSchema", or "fingerprint" in lock
+	// verification diffs (see Verify).
+	Field string `json:"field"`
+
+	// Path locates the change inside a schema. Empty for scalar fields.
+	Path string `json:"path,omitempty"`
+
+	Old any `json:"old,omitempty"`
+	New any `json:"new,omitempty"`
+}
+
+// String renders the change as "field/path: old -> new".
+func (c FieldChange) String() string {
+	return c.Field + c.Path + ": " + formatDiffValue(c.Old) + " -> " + formatDiffValue(c.New)
+}
+
+// Diff compares two toolsets. A nil toolset is treated as empty.
+//
+// Tools are matched by ID. Modified tools are compared on description,
+// tags and scopes (as sets), category, and a structural diff of the input
+// and output schemas.
+func Diff(a, b *Toolset) *ToolsetDiff {
+	d := &ToolsetDiff{Added: []string{}, Removed: []string{}, Modified: []ToolDiff{}}
+	var before, after []*tooladapter.CanonicalTool
+	if a != nil {
+		d.From = a.Name()
+		before = a.Tools()
+	}
+	if b != nil {
+		d.To = b.Name()
+		after = b.Tools()
+	}
+
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case j == len(after) || i < len(before) && before[i].ID() < after[j].ID():
+			d.Removed = append(d.Removed, before[i].ID())
+			i++
+		case i == len(before) || after[j].ID() < before[i].ID():
+			d.Added = append(d.Added, after[j].ID())
+			j++
+		default:
+			if changes := DiffTools(before[i], after[j]); len(changes) > 0 {
+				d.Modified = append(d.Modified, ToolDiff{ID: after[j].ID(), Changes: changes})
+			}
+			i++
+			j++
+		}
+	}
+	return d
+}
+
+// DiffTools returns the field changes between two versions of a tool,
+// in field order and then by schema path. Nil tools are treated as empty.
+func DiffTools(a, b *tooladapter.CanonicalTool) []FieldChange {
+	if a == nil {
+		a = &tooladapter.CanonicalTool{}
+	}
+	if b == nil {
+		b = &tooladapter.CanonicalTool{}
+	}
+
+	var changes []FieldChange
+	if a.Description != b.Description {
+		changes = append(changes, FieldChange{Field: "description", Old: a.Description, New: b.Description})
+	}
+	if oldTags, newTags := sortedSet(a.Tags), sortedSet(b.Tags); !reflect.DeepEqual(oldTags, newTags) {
+		changes = append(changes, FieldChange{Field: "tags", Old: oldTags, New: newTags})
+	}
+	if a.Category != b.Category {
+		changes = append(changes, FieldChange{Field: "category", Old: a.Category, New: b.Category})
+	}
+	if oldScopes, newScopes := sortedSet(a.RequiredScopes), sortedSet(b.RequiredScopes); !reflect.DeepEqual(oldScopes, newScopes) {
+		changes = append(changes, FieldChange{Field: "scopes", Old: oldScopes, New: newScopes})
+	}
+	changes = diffSchemaValue(changes, "inputSchema", "", schemaValue(a.InputSchema), schemaValue(b.InputSchema))
+	changes = diffSchemaValue(changes, "outputSchema", "", schemaValue(a.OutputSchema), schemaValue(b.OutputSchema))
+	return changes
+}
+
+// Empty reports whether the toolsets are identical on the compared fields.
+func (d *ToolsetDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// String renders the diff as text: "+" for added, "-" for removed and "~"
+// for modified tools, followed by one indented line per field change.
+func (d *ToolsetDiff) String() string {
+	type line struct {
+		id   string
+		text string
+	}
+	lines := make([]line, 0, len(d.Added)+len(d.Removed)+len(d.Modified))
+	for _, id := range d.Added {
+		lines = append(lines, line{id, "+ " + id + "\n"})
+	}
+	for _, id := range d.Removed {
+		lines = append(lines, line{id, "- " + id + "\n"})
+	}
+	for _, m := range d.Modified {
+		var sb strings.Builder
+		sb.WriteString("~ " + m.ID + "\n")
+		for _, c := range m.Changes {
+			sb.WriteString("    " + c.String() + "\n")
+		}
+		lines = append(lines, line{m.ID, sb.String()})
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].id < lines[j].id })
+
+	var sb strings.Builder
+	for _, l := range lines {
+		sb.WriteString(l.text)
+	}
+	return sb.String()
+}
+
+// schemaValue returns the generic form of a schema, or nil.
+func schemaValue(s *tooladapter.JSONSchema) any {
+	if s == nil {
+		return nil
+	}
+	return s.ToMap()
+}
+
+// diffSchemaValue appends the structural differences between two generic
+// schema values. Objects are compared key by key; arrays containing objects
+// (anyOf, oneOf, allOf) element by element; other values as a whole.
+func diffSchemaValue(changes []FieldChange, field, path string, a, b any) []FieldChange {
+	am, aIsMap := a.(map[string]any)
+	bm, bIsMap := b.(map[string]any)
+	if aIsMap && bIsMap {
+		keys := make([]string, 0, len(am)+len(bm))
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, ok := am[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			changes = diffSchemaValue(changes, field, path+"/"+escapePointer(k), am[k], bm[k])
+		}
+		return changes
+	}
+
+	as, aIsSlice := a.([]any)
+	bs, bIsSlice := b.([]any)
+	if aIsSlice && bIsSlice && (containsMap(as) || containsMap(bs)) {
+		for i := 0; i < len(as) || i < len(bs); i++ {
+			var av, bv any
+			if i < len(as) {
+				av = as[i]
+			}
+			if i < len(bs) {
+				bv = bs[i]
+			}
+			changes = diffSchemaValue(changes, field, path+"/"+strconv.Itoa(i), av, bv)
+		}
+		return changes
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		changes = append(changes, FieldChange{Field: field, Path: path, Old: a, New: b})
+	}
+	return changes
+}
+
+// escapePointer escapes a JSON pointer reference token (RFC 6901).
+func escapePointer(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
+}
+
+func containsMap(values []any) bool {
+	for _, v := range values {
+		if _, ok := v.(map[string]any); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedSet returns the sorted, de-duplicated values (nil if empty).
+func sortedSet(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := append([]string(nil), values...)
+	sort.Strings(out)
+	n := 1
+	for i := 1; i < len(out); i++ {
+		if out[i] != out[n-1] {
+			out[n] = out[i]
+			n++
+		}
+	}
+	return out[:n]
+}
+
+// formatDiffValue renders a value for text diffs as compact JSON.
+func formatDiffValue(v any) string {
+	if v == nil {
+		return "(none)"
+	}
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,144 @@
+package toolset
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func searchTool() *tooladapter.CanonicalTool {
+	return &tooladapter.CanonicalTool{
+		Namespace:   "mcp",
+		Name:        "search",
+		Description: "Search",
+		Tags:        []string{"read", "safe"},
+		InputSchema: &tooladapter.JSONSchema{
+			Type: "object",
+			Properties: map[string]*tooladapter.JSONSchema{
+				"query": {Type: "string"},
+				"limit": {Type: "integer"},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := New("v1")
+	a.Add(searchTool())
+	a.Add(makeTool("mcp", "old", nil))
+	a.Add(makeTool("mcp", "same", []string{"x"}))
+
+	changed := searchTool()
+	changed.Description = "Search resources"
+	changed.Tags = []string{"safe", "read"} // same set, different order
+	changed.RequiredScopes = []string{"read"}
+	changed.InputSchema.Properties["query"].Pattern = "^[a-z]+$"
+	changed.InputSchema.Properties["a/b"] = &tooladapter.JSONSchema{Type: "string"}
+	delete(changed.InputSchema.Properties, "limit")
+	changed.OutputSchema = &tooladapter.JSONSchema{Type: "object"}
+
+	b := New("v2")
+	b.Add(changed)
+	b.Add(makeTool("mcp", "new", nil))
+	b.Add(makeTool("mcp", "same", []string{"x"}))
+
+	d := Diff(a, b)
+	if d.From != "v1" || d.To != "v2" {
+		t.Errorf("From/To = %q/%q", d.From, d.To)
+	}
+	if !reflect.DeepEqual(d.Added, []string{"mcp:new"}) || !reflect.DeepEqual(d.Removed, []string{"mcp:old"}) {
+		t.Errorf("Added = %v, Removed = %v", d.Added, d.Removed)
+	}
+	if len(d.Modified) != 1 || d.Modified[0].ID != "mcp:search" {
+		t.Fatalf("Modified = %+v", d.Modified)
+	}
+
+	var got []string
+	for _, c := range d.Modified[0].Changes {
+		got = append(got, c.Field+c.Path)
+	}
+	want := []string{
+		"description",
+		"scopes",
+		"inputSchema/properties/a~1b",
+		"inputSchema/properties/limit",
+		"inputSchema/properties/query/pattern",
+		"outputSchema",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+
+	if d.Empty() {
+		t.Error("Empty() = true")
+	}
+	if !Diff(a, a).Empty() {
+		t.Error("Diff(a, a) should be empty")
+	}
+}
+
+func TestDiff_Nil(t *testing.T) {
+	b := New("b")
+	b.Add(makeTool("ns", "a", nil))
+	d := Diff(nil, b)
+	if !reflect.DeepEqual(d.Added, []string{"ns:a"}) {
+		t.Errorf("Added = %v", d.Added)
+	}
+	if d := Diff(nil, nil); !d.Empty() {
+		t.Errorf("Diff(nil, nil) = %+v", d)
+	}
+}
+
+func TestDiffTools_Combinators(t *testing.T) {
+	a := &tooladapter.CanonicalTool{Name: "x", InputSchema: &tooladapter.JSONSchema{
+		AnyOf: []*tooladapter.JSONSchema{{Type: "string"}, {Type: "integer"}},
+		Enum:  []any{"a", "b"},
+	}}
+	b := &tooladapter.CanonicalTool{Name: "x", InputSchema: &tooladapter.JSONSchema{
+		AnyOf: []*tooladapter.JSONSchema{{Type: "string"}, {Type: "number"}, {Type: "null"}},
+		Enum:  []any{"a", "c"},
+	}}
+	var got []string
+	for _, c := range DiffTools(a, b) {
+		got = append(got, c.Path)
+	}
+	want := []string{"/anyOf/1/type", "/anyOf/2", "/enum"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %q, want %q", got, want)
+	}
+}
+
+func TestToolsetDiff_Render(t *testing.T) {
+	a := New("v1")
+	a.Add(searchTool())
+	a.Add(makeTool("mcp", "zzz", nil))
+	b := New("v2")
+	changed := searchTool()
+	changed.Description = "Search resources"
+	changed.InputSchema.Properties["query"].Type = "integer"
+	b.Add(changed)
+	b.Add(makeTool("mcp", "aaa", nil))
+
+	d := Diff(a, b)
+	wantText := `+ mcp:aaa
+~ mcp:search
+    description: "Search" -> "Search resources"
+    inputSchema/properties/query/type: "string" -> "integer"
+- mcp:zzz
+`
+	if got := d.String(); got != wantText {
+		t.Errorf("String() =\n%s\nwant\n%s", got, wantText)
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	wantJSON := `{"from":"v1","to":"v2","added":["mcp:aaa"],"removed":["mcp:zzz"],"modified":[{"id":"mcp:search","changes":[{"field":"description","old":"Search","new":"Search resources"},{"field":"inputSchema","path":"/properties/query/type","old":"string","new":"integer"}]}]}`
+	if string(data) != wantJSON {
+		t.Errorf("JSON =\n%s\nwant\n%s", data, wantJSON)
+	}
+}
@@ -0,0 +1,117 @@
+package toolset
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// Generation returns a counter that increases on every effective Add or
+// Remove. It equals Snapshot().Version().
+func (ts *Toolset) Generation() uint64 {
+	return ts.Snapshot().Version()
+}
+
+// ETag returns a strong HTTP entity tag, including the quotes, derived from
+// the content of every tool. Toolsets with the same tools have the same
+// ETag regardless of name or mutation history.
+func (ts *Toolset) ETag() string {
+	return ts.Snapshot().ETag()
+}
+
+// ETag returns the entity tag of the snapshot's tools (see Toolset.ETag).
+// It is computed once per snapshot.
+func (s *Snapshot) ETag() string {
+	s.etagOnce.Do(func() {
+		h := sha256.New()
+		var buf bytes.Buffer
+		for _, t := range s.tools {
+			buf.Reset()
+			writeToolContent(&buf, t)
+			h.Write(buf.Bytes())
+		}
+		s.etag = quoteETag(h)
+	})
+	return s.etag
+}
+
+// writeToolContent appends the content of t to buf in the canonical
+// encoding used by CanonicalJSON. Unlike the fingerprint it covers every
+// field as exported, including Version, Timeout and SourceMeta, and keeps
+// tag and "required" order. The encoding cannot fail and writes values
+// JSON cannot represent (NaN, maps with non-string keys) as distinct
+// non-JSON tokens, so they change the tag and never match a string such
+// as "NaN".
+func writeToolContent(buf *bytes.Buffer, t *tooladapter.CanonicalTool) {
+	writeCanonical(buf, map[string]any{
+		"namespace":       t.Namespace,
+		"name":            t.Name,
+		"version":         t.Version,
+		"description":     t.Description,
+		"category":        t.Category,
+		"tags":            t.Tags,
+		"required_scopes": t.RequiredScopes,
+		"input_schema":    schemaContent(t.InputSchema),
+		"output_schema":   schemaContent(t.OutputSchema),
+		"timeout":         t.Timeout.String(),
+		"source_format":   t.SourceFormat,
+		"source_meta":     t.SourceMeta,
+	})
+}
+
+// schemaContent returns the keyword map of s, or nil.
+func schemaContent(s *tooladapter.JSONSchema) any {
+	if s == nil {
+		return nil
+	}
+	return s.ToMap()
+}
+
+// quoteETag formats the first 128 bits of h's sum as a quoted entity tag.
+func quoteETag(h hash.Hash) string {
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// ETag returns an entity tag for the current export output. It combines
+// the toolset's ETag with the adapter name and the options that change the
+// output (naming strategy and schema lowering), so conditional requests
+// stay correct when any of them changes.
+//
+// To tag a response, prefer ExportWithETag: ETag followed by Export can
+// race with a concurrent mutation of the toolset.
+func (e *Exposure) ETag() string {
+	return e.etag(e.toolset.Snapshot())
+}
+
+// ExportWithETag is like Export but also returns the entity tag of the
+// exported tools. Both come from the same snapshot.
+func (e *Exposure) ExportWithETag() ([]any, string, error) {
+	if err := e.check(); err != nil {
+		return nil, "", err
+	}
+	snap := e.toolset.Snapshot()
+	result, err := e.exportTools(snap.tools)
+	if err != nil {
+		return nil, "", err
+	}
+	return result, e.etag(snap), nil
+}
+
+// etag derives the Exposure's entity tag for a snapshot.
+func (e *Exposure) etag(s *Snapshot) string {
+	h := sha256.New()
+	h.Write([]byte(s.ETag()))
+	adapter := ""
+	if e.adapter != nil {
+		adapter = e.adapter.Name()
+	}
+	fmt.Fprintf(h, "\x00adapter=%q\x00lower=%t", adapter, e.lowerSchemas)
+	if n := e.naming; n != nil {
+		fmt.Fprintf(h, "\x00naming=%q,%d,%q,%q", n.Separator, n.MaxLength, n.Prefix, n.Suffix)
+	}
+	return quoteETag(h)
+}
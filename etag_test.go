@@ -0,0 +1,146 @@
+package toolset
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func TestToolset_Generation(t *testing.T) {
+	ts := New("gen")
+	a := makeTool("ns", "a", nil)
+	steps := []struct {
+		name string
+		op   func()
+		want uint64
+	}{
+		{"add", func() { ts.Add(a) }, 1},
+		{"re-add same pointer", func() { ts.Add(a) }, 1},
+		{"replace", func() { ts.Add(makeTool("ns", "a", []string{"x"})) }, 2},
+		{"remove missing", func() { ts.Remove("ns:missing") }, 2},
+		{"remove", func() { ts.Remove("ns:a") }, 3},
+	}
+	for _, step := range steps {
+		step.op()
+		if got := ts.Generation(); got != step.want {
+			t.Errorf("after %s: Generation() = %d, want %d", step.name, got, step.want)
+		}
+	}
+}
+
+func TestToolset_ETag(t *testing.T) {
+	t.Run("derived from content", func(t *testing.T) {
+		a, b := New("a"), New("b")
+		a.Add(makeTool("ns", "x", []string{"read"}))
+		a.Add(makeTool("ns", "y", nil))
+		b.Add(makeTool("ns", "y", nil))
+		b.Add(makeTool("ns", "x", []string{"read"}))
+		if a.ETag() != b.ETag() {
+			t.Errorf("equal content: %s != %s", a.ETag(), b.ETag())
+		}
+		if !strings.HasPrefix(a.ETag(), `"`) || !strings.HasSuffix(a.ETag(), `"`) || len(a.ETag()) != 34 {
+			t.Errorf("ETag() = %s, want quoted 32 hex digits", a.ETag())
+		}
+
+		before := a.ETag()
+		a.Add(makeTool("ns", "x", []string{"write"}))
+		if a.ETag() == before {
+			t.Error("ETag() unchanged after a tool changed")
+		}
+		a.Add(makeTool("ns", "x", []string{"read"}))
+		if a.ETag() != before {
+			t.Error("ETag() should return to the previous value for the previous content")
+		}
+	})
+
+	t.Run("schema changes are detected", func(t *testing.T) {
+		ts := New("s")
+		tool := makeTool("ns", "x", nil)
+		tool.InputSchema = &tooladapter.JSONSchema{Type: "object"}
+		ts.Add(tool)
+		before := ts.ETag()
+		changed := *tool
+		changed.InputSchema = &tooladapter.JSONSchema{Type: "object", Required: []string{"q"}}
+		ts.Add(&changed)
+		if ts.ETag() == before {
+			t.Error("ETag() unchanged after schema change")
+		}
+	})
+
+	t.Run("unencodable schema changes are detected", func(t *testing.T) {
+		ts := New("s")
+		tool := makeTool("ns", "x", nil)
+		tool.InputSchema = &tooladapter.JSONSchema{Type: "number", Default: math.NaN(), Enum: []any{map[any]any{1: "a"}}}
+		ts.Add(tool)
+		before := ts.ETag()
+
+		changed := *tool
+		changed.InputSchema = &tooladapter.JSONSchema{Type: "number", Default: math.NaN(), Enum: []any{map[any]any{1: "b"}}}
+		ts.Add(&changed)
+		if ts.ETag() == before {
+			t.Error("ETag() unchanged after enum change")
+		}
+		before = ts.ETag()
+
+		changed2 := changed
+		changed2.Description = "changed"
+		ts.Add(&changed2)
+		if ts.ETag() == before {
+			t.Error("ETag() unchanged after description change")
+		}
+
+		asString := changed2
+		asString.InputSchema = &tooladapter.JSONSchema{Type: "number", Default: "NaN", Enum: []any{map[any]any{1: "b"}}}
+		before = ts.ETag()
+		ts.Add(&asString)
+		if ts.ETag() == before {
+			t.Error(`ETag() unchanged after NaN became "NaN"`)
+		}
+	})
+
+	t.Run("unencodable source metadata", func(t *testing.T) {
+		ts := New("s")
+		tool := makeTool("ns", "x", nil)
+		tool.SourceMeta = map[string]any{"fn": func() {}, "v": 1}
+		ts.Add(tool)
+		if ts.ETag() == New("s").ETag() {
+			t.Error("ETag() should still cover the tool")
+		}
+	})
+}
+
+func TestExposure_ETag(t *testing.T) {
+	ts := pagedToolset("a", "b")
+	exp := NewExposure(ts, &mockAdapter{name: "mock"})
+
+	tools, etag, err := exp.ExportWithETag()
+	if err != nil || len(tools) != 2 {
+		t.Fatalf("ExportWithETag() = %d tools, %v", len(tools), err)
+	}
+	if etag != exp.ETag() {
+		t.Errorf("ExportWithETag() tag %s != ETag() %s", etag, exp.ETag())
+	}
+	if etag == ts.ETag() {
+		t.Error("Exposure ETag should differ from the toolset ETag")
+	}
+
+	other := NewExposure(ts, &mockAdapter{name: "other"})
+	named := NewExposure(ts, &mockAdapter{name: "mock"}).WithNaming(NamingStrategy{})
+	lowered := NewExposure(ts, &mockAdapter{name: "mock"}).WithSchemaLowering(true)
+	for name, e := range map[string]*Exposure{"adapter": other, "naming": named, "lowering": lowered} {
+		if e.ETag() == etag {
+			t.Errorf("%s: ETag() should change with the option", name)
+		}
+	}
+
+	ts.Add(makeTool("ns", "c", nil))
+	if exp.ETag() == etag {
+		t.Error("ETag() unchanged after toolset mutation")
+	}
+
+	if _, _, err := NewExposure(ts, nil).ExportWithETag(); err == nil {
+		t.Error("ExportWithETag() should fail for nil adapter")
+	}
+}
@@ -0,0 +1,197 @@
+package toolset
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// EventType identifies the kind of Toolset mutation.
+type EventType int
+
+const (
+	// EventAdded is emitted when Add inserts a tool under a new ID.
+	EventAdded EventType = iota
+	// EventReplaced is emitted when Add stores a different tool under an existing ID.
+	EventReplaced
+	// EventRemoved is emitted when Remove deletes a tool.
+	EventRemoved
+)
+
+// String returns "added", "replaced" or "removed".
+func (e EventType) String() string {
+	switch e {
+	case EventAdded:
+		return "added"
+	case EventReplaced:
+		return "replaced"
+	case EventRemoved:
+		return "removed"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(e))
+	}
+}
+
+// Event describes a change to a Toolset.
+type Event struct {
+	// Type is the kind of change.
+	Type EventType
+
+	// ID is the affected tool ID.
+	ID string
+
+	// Old is the previous tool (nil for EventAdded).
+	Old *tooladapter.CanonicalTool
+
+	// New is the new tool (nil for EventRemoved).
+	New *tooladapter.CanonicalTool
+}
+
+// Subscribe registers fn to be called for every effective mutation.
+//
+// Events are delivered in mutation order, after the toolset lock is released,
+// and before the mutating Add or Remove call returns. Re-adding the same tool
+// pointer is not a mutation and emits nothing. fn may read the toolset and
+// cancel subscriptions, including its own, but must not call Add or Remove.
+// A subscription canceled during delivery may still receive the remaining
+// events of the batch being delivered. The returned function cancels the
+// subscription.
+func (ts *Toolset) Subscribe(fn func(Event)) (cancel func()) {
+	if fn == nil {
+		return func() {}
+	}
+	sub := &subscriber{deliver: fn}
+	ts.addSubscriber(sub)
+	return func() { ts.removeSubscriber(sub) }
+}
+
+// Subscription is a buffered-channel subscription created by Toolset.Events.
+type Subscription struct {
+	// C receives events. It is closed by Cancel.
+	C <-chan Event
+
+	ts      *Toolset
+	sub     *subscriber
+	ch      chan Event
+	dropped atomic.Uint64
+
+	mu     sync.Mutex // orders sends with the close in Cancel
+	closed bool
+}
+
+// Events returns a subscription that delivers events on a channel with the
+// given buffer size. Delivery never blocks the mutating goroutine: events
+// that do not fit in the buffer are dropped and counted by Dropped.
+func (ts *Toolset) Events(buffer int) *Subscription {
+	if buffer < 0 {
+		buffer = 0
+	}
+	ch := make(chan Event, buffer)
+	s := &Subscription{C: ch, ts: ts, ch: ch}
+	s.sub = &subscriber{deliver: s.send, onCancel: s.close}
+	ts.addSubscriber(s.sub)
+	return s
+}
+
+// Cancel stops delivery and closes C. Cancel is idempotent.
+func (s *Subscription) Cancel() {
+	s.ts.removeSubscriber(s.sub)
+}
+
+// send delivers ev without blocking unless the subscription is closed.
+func (s *Subscription) send(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- ev:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// close closes C once.
+func (s *Subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// Dropped returns the number of events dropped because the buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// subscriber is a registered event consumer.
+type subscriber struct {
+	deliver  func(Event)
+	onCancel func()
+}
+
+func (ts *Toolset) addSubscriber(sub *subscriber) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	subs := make([]*subscriber, len(ts.subs), len(ts.subs)+1)
+	copy(subs, ts.subs)
+	ts.subs = append(subs, sub)
+}
+
+func (ts *Toolset) removeSubscriber(sub *subscriber) {
+	ts.mu.Lock()
+	found := false
+	subs := make([]*subscriber, 0, len(ts.subs))
+	for _, s := range ts.subs {
+		if s == sub {
+			found = true
+			continue
+		}
+		subs = append(subs, s)
+	}
+	ts.subs = subs
+	ts.mu.Unlock()
+
+	// The hook does not wait for delivery, so handlers may cancel
+	// subscriptions; it synchronizes with the subscriber's own sends.
+	if found && sub.onCancel != nil {
+		sub.onCancel()
+	}
+}
+
+// enqueue records ev for delivery and reports whether a flush is needed.
+// Callers must hold ts.mu.
+func (ts *Toolset) enqueue(ev Event) bool {
+	if len(ts.subs) == 0 {
+		return false
+	}
+	ts.pending = append(ts.pending, ev)
+	return true
+}
+
+// flush delivers pending events in order. Callers must not hold ts.mu.
+// When another goroutine is already delivering, flush waits for it; that
+// goroutine drains the queue, so on return the caller's events are delivered.
+func (ts *Toolset) flush() {
+	ts.deliverMu.Lock()
+	defer ts.deliverMu.Unlock()
+	for {
+		ts.mu.Lock()
+		events, subs := ts.pending, ts.subs
+		ts.pending = nil
+		ts.mu.Unlock()
+		if len(events) == 0 {
+			return
+		}
+		for _, ev := range events {
+			for _, s := range subs {
+				s.deliver(ev)
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package toolset
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestToolset_Subscribe(t *testing.T) {
+	t.Run("added, replaced and removed events", func(t *testing.T) {
+		ts := New("test")
+		var events []Event
+		cancel := ts.Subscribe(func(ev Event) { events = append(events, ev) })
+		defer cancel()
+
+		a1 := makeTool("ns", "a", nil)
+		a2 := makeTool("ns", "a", []string{"v2"})
+		ts.Add(a1)
+		ts.Add(a1) // same pointer: no event
+		ts.Add(a2)
+		ts.Remove("ns:a")
+		ts.Remove("ns:a") // already removed: no event
+
+		want := []Event{
+			{Type: EventAdded, ID: "ns:a", New: a1},
+			{Type: EventReplaced, ID: "ns:a", Old: a1, New: a2},
+			{Type: EventRemoved, ID: "ns:a", Old: a2},
+		}
+		if len(events) != len(want) {
+			t.Fatalf("got %d events, want %d: %+v", len(events), len(want), events)
+		}
+		for i := range want {
+			if events[i] != want[i] {
+				t.Errorf("event[%d] = %+v, want %+v", i, events[i], want[i])
+			}
+		}
+	})
+
+	t.Run("handler may read the toolset", func(t *testing.T) {
+		ts := New("test")
+		var counts []int
+		ts.Subscribe(func(Event) { counts = append(counts, ts.Count()) })
+		ts.Add(makeTool("ns", "a", nil))
+		ts.Add(makeTool("ns", "b", nil))
+		if len(counts) != 2 || counts[0] != 1 || counts[1] != 2 {
+			t.Errorf("counts = %v, want [1 2]", counts)
+		}
+	})
+
+	t.Run("cancel stops delivery", func(t *testing.T) {
+		ts := New("test")
+		calls := 0
+		cancel := ts.Subscribe(func(Event) { calls++ })
+		ts.Add(makeTool("ns", "a", nil))
+		cancel()
+		cancel()
+		ts.Add(makeTool("ns", "b", nil))
+		if calls != 1 {
+			t.Errorf("calls = %d, want 1", calls)
+		}
+	})
+
+	t.Run("events delivered in mutation order under concurrency", func(t *testing.T) {
+		ts := New("test")
+		var mu sync.Mutex
+		present := map[string]bool{}
+		ts.Subscribe(func(ev Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch ev.Type {
+			case EventAdded:
+				if present[ev.ID] {
+					t.Errorf("added %s twice without removal", ev.ID)
+				}
+				present[ev.ID] = true
+			case EventRemoved:
+				if !present[ev.ID] {
+					t.Errorf("removed %s before it was added", ev.ID)
+				}
+				delete(present, ev.ID)
+			}
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					ts.Add(makeTool("ns", "x", nil))
+					ts.Remove("ns:x")
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestToolset_Events(t *testing.T) {
+	t.Run("buffered delivery", func(t *testing.T) {
+		ts := New("test")
+		sub := ts.Events(4)
+		ts.Add(makeTool("ns", "a", nil))
+		ts.Remove("ns:a")
+
+		if ev := <-sub.C; ev.Type != EventAdded || ev.ID != "ns:a" {
+			t.Errorf("first event = %+v", ev)
+		}
+		if ev := <-sub.C; ev.Type != EventRemoved {
+			t.Errorf("second event = %+v", ev)
+		}
+		sub.Cancel()
+		if _, ok := <-sub.C; ok {
+			t.Error("channel should be closed after Cancel")
+		}
+		sub.Cancel()
+	})
+
+	t.Run("full buffer drops without blocking", func(t *testing.T) {
+		ts := New("test")
+		sub := ts.Events(1)
+		defer sub.Cancel()
+		ts.Add(makeTool("ns", "a", nil))
+		ts.Add(makeTool("ns", "b", nil))
+		ts.Add(makeTool("ns", "c", nil))
+		if sub.Dropped() != 2 {
+			t.Errorf("Dropped() = %d, want 2", sub.Dropped())
+		}
+		if ev := <-sub.C; ev.ID != "ns:a" {
+			t.Errorf("buffered event = %+v, want ns:a", ev)
+		}
+	})
+
+	t.Run("cancel from a handler", func(t *testing.T) {
+		ts := New("test")
+		sub := ts.Events(4)
+		var cancelSelf func()
+		calls := 0
+		cancelSelf = ts.Subscribe(func(Event) {
+			calls++
+			sub.Cancel()
+			cancelSelf()
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			ts.Add(makeTool("ns", "a", nil))
+			ts.Add(makeTool("ns", "b", nil))
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Add deadlocked when a handler canceled a subscription")
+		}
+		if calls != 1 {
+			t.Errorf("handler called %d times, want 1", calls)
+		}
+		for range sub.C { // drains and ends once closed
+		}
+	})
+}
+
+func TestEventType_String(t *testing.T) {
+	for typ, want := range map[EventType]string{EventAdded: "added", EventReplaced: "replaced", EventRemoved: "removed"} {
+		if typ.String() != want {
+			t.Errorf("String() = %q, want %q", typ.String(), want)
+		}
+	}
+}
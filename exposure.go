@@ -2,14 +2,19 @@ package toolset
 
 import (
 	"errors"
+	"sort"
+	"strconv"
 
 	"github.com/jonwraymond/tooladapter"
 )
 
 // Exposure exports a Toolset to protocol-specific formats.
 type Exposure struct {
-	toolset *Toolset
-	adapter tooladapter.Adapter
+	toolset       *Toolset
+	adapter       tooladapter.Adapter
+	dedupWarnings bool
+	lowerSchemas  bool
+	naming        *NamingStrategy
 }
 
 // NewExposure creates an Exposure for the given toolset and adapter.
@@ -18,14 +23,28 @@ func NewExposure(ts *Toolset, adapter tooladapter.Adapter) *Exposure {
 }
 
 // Export converts all tools to the adapter's format.
+// It stops at the first conversion error or name collision.
 func (e *Exposure) Export() ([]any, error) {
-	if e.adapter == nil {
-		return nil, errors.New("adapter is nil")
+	if err := e.check(); err != nil {
+		return nil, err
+	}
+	return e.exportTools(e.toolset.Tools())
+}
+
+// exportTools implements Export for a tool snapshot. The configuration
+// must have passed check.
+func (e *Exposure) exportTools(tools []*tooladapter.CanonicalTool) ([]any, error) {
+	var names *NameMap
+	if e.naming != nil {
+		var collisions map[string]error
+		names, collisions = assignNames(e.naming.Name, tools)
+		if errs := collisionErrors(collisions); len(errs) > 0 {
+			return nil, errs[0]
+		}
 	}
-	tools := e.toolset.Tools()
 	result := make([]any, 0, len(tools))
 	for _, t := range tools {
-		converted, err := e.adapter.FromCanonical(t)
+		converted, err := e.convert(t, names)
 		if err != nil {
 			return nil, err
 		}
@@ -34,40 +53,112 @@ func (e *Exposure) Export() ([]any, error) {
 	return result, nil
 }
 
+// convert lowers, renames and converts a single tool. names is nil
+// without a naming strategy.
+func (e *Exposure) convert(t *tooladapter.CanonicalTool, names *NameMap) (any, error) {
+	lowered, _ := e.prepare(t, "")
+	if names != nil {
+		name, _ := names.Name(t.ID())
+		lowered = rename(lowered, name)
+	}
+	return e.adapter.FromCanonical(lowered)
+}
+
+// check validates the Exposure's configuration.
+func (e *Exposure) check() error {
+	if e.adapter == nil {
+		return errors.New("adapter is nil")
+	}
+	if e.naming != nil {
+		return e.naming.Validate()
+	}
+	return nil
+}
+
 // ExportWithWarnings converts tools and returns feature loss warnings and conversion errors.
 // Unlike Export, this method continues on conversion errors and collects them for reporting.
 // Callers should check the errors slice to detect tools that failed to convert.
+//
+// Warnings are ordered as described for ExportWithSchemaWarnings.
 func (e *Exposure) ExportWithWarnings() ([]any, []tooladapter.FeatureLossWarning, []error) {
-	if e.adapter == nil {
-		return nil, nil, []error{errors.New("adapter is nil")}
+	result, schemaWarnings, errs := e.ExportWithSchemaWarnings()
+	var warnings []tooladapter.FeatureLossWarning
+	for _, w := range schemaWarnings {
+		warnings = append(warnings, w.FeatureLossWarning)
 	}
+	return result, warnings, errs
+}
 
-	tools := e.toolset.Tools()
-	result := make([]any, 0, len(tools))
-	var warnings []tooladapter.FeatureLossWarning
-	var errs []error
+// ExportWithSchemaWarnings is like ExportWithWarnings but locates each
+// warning by tool ID, schema and JSON pointer.
+//
+// Warnings are sorted by tool ID, schema ("input" before "output"), pointer
+// and feature, so identical toolsets always produce identical output.
+func (e *Exposure) ExportWithSchemaWarnings() ([]any, []SchemaWarning, []error) {
+	if err := e.check(); err != nil {
+		return nil, nil, []error{err}
+	}
 
+	res := e.export(e.toolset.Tools())
+	return res.tools, res.warnings, res.errs
+}
+
+// exportResult is the outcome of converting a tool snapshot.
+type exportResult struct {
+	tools    []any
+	ids      []string // IDs of tools, index-aligned
+	names    *NameMap // nil without a naming strategy
+	warnings []SchemaWarning
+	errs     []error
+}
+
+// export converts tools with the Exposure's adapter and options.
+// The configuration must have passed check.
+func (e *Exposure) export(tools []*tooladapter.CanonicalTool) exportResult {
+	res := exportResult{tools: make([]any, 0, len(tools)), ids: make([]string, 0, len(tools))}
+	var collisions map[string]error
+	if e.naming != nil {
+		res.names, collisions = assignNames(e.naming.Name, tools)
+	}
 	for _, t := range tools {
+		if err, ok := collisions[t.ID()]; ok {
+			res.errs = append(res.errs, &ConversionError{ToolID: t.ID(), Cause: err})
+			continue
+		}
 		sourceName := t.SourceFormat
 		if sourceName == "" {
 			sourceName = "canonical"
 		}
 
-		warnings = append(warnings, detectSchemaFeatureLoss(t.InputSchema, sourceName, e.adapter)...)
-		warnings = append(warnings, detectSchemaFeatureLoss(t.OutputSchema, sourceName, e.adapter)...)
+		lowered, actions := e.prepare(t, sourceName)
+		res.warnings = append(res.warnings, actions...)
+		loss := featureLoss{toolID: t.ID(), from: sourceName, adapter: e.adapter}
+		res.warnings = loss.detect(res.warnings, "input", "", lowered.InputSchema)
+		res.warnings = loss.detect(res.warnings, "output", "", lowered.OutputSchema)
+
+		if res.names != nil {
+			name, _ := res.names.Name(t.ID())
+			lowered = rename(lowered, name)
+		}
 
 		// Convert
-		converted, err := e.adapter.FromCanonical(t)
+		converted, err := e.adapter.FromCanonical(lowered)
 		if err != nil {
-			errs = append(errs, &ConversionError{
+			res.errs = append(res.errs, &ConversionError{
 				ToolID: t.ID(),
 				Cause:  err,
 			})
 			continue
 		}
-		result = append(result, converted)
+		res.tools = append(res.tools, converted)
+		res.ids = append(res.ids, t.ID())
 	}
-	return result, warnings, errs
+
+	sortSchemaWarnings(res.warnings)
+	if e.dedupWarnings {
+		res.warnings = dedupSchemaWarnings(res.warnings)
+	}
+	return res
 }
 
 // ConversionError represents a tool that failed to convert.
@@ -84,67 +175,203 @@ func (e *ConversionError) Unwrap() error {
 	return e.Cause
 }
 
-// detectSchemaFeatureLoss checks which features in a schema are not supported.
-func detectSchemaFeatureLoss(schema *tooladapter.JSONSchema, sourceName string, adapter tooladapter.Adapter) []tooladapter.FeatureLossWarning {
+// SchemaWarning is a FeatureLossWarning located within a tool's schema.
+type SchemaWarning struct {
+	tooladapter.FeatureLossWarning
+
+	// ToolID is the ID of the affected tool.
+	ToolID string
+
+	// Schema is "input" or "output".
+	Schema string
+
+	// Pointer is a JSON pointer to the keyword that uses the feature,
+	// e.g. "/properties/query/pattern".
+	Pointer string
+
+	// Action is the lowering applied to the feature (see WithSchemaLowering).
+	// Empty when the feature was passed to the adapter unchanged.
+	Action LoweringAction
+
+	// Detail qualifies Action, e.g. the inlined reference.
+	Detail string
+}
+
+// String renders the warning as "tool input/pointer: message".
+func (w SchemaWarning) String() string {
+	prefix := w.ToolID + " " + w.Schema + w.Pointer + ": "
+	if w.Action == "" {
+		return prefix + w.FeatureLossWarning.String()
+	}
+	msg := prefix + w.Feature.String() + " " + string(w.Action) + " for " + w.ToAdapter
+	if w.Detail != "" {
+		msg += " (" + w.Detail + ")"
+	}
+	return msg
+}
+
+// WithWarningDedup collapses schema warnings to one per tool, feature and
+// lowering action, keeping the first in sort order. It returns e for chaining.
+func (e *Exposure) WithWarningDedup(dedup bool) *Exposure {
+	e.dedupWarnings = dedup
+	return e
+}
+
+// featureKeywords maps each feature to its JSON Schema keyword.
+var featureKeywords = map[tooladapter.SchemaFeature]string{
+	tooladapter.FeatureRef:                  "$ref",
+	tooladapter.FeatureDefs:                 "$defs",
+	tooladapter.FeatureAnyOf:                "anyOf",
+	tooladapter.FeatureOneOf:                "oneOf",
+	tooladapter.FeatureAllOf:                "allOf",
+	tooladapter.FeatureNot:                  "not",
+	tooladapter.FeaturePattern:              "pattern",
+	tooladapter.FeatureFormat:               "format",
+	tooladapter.FeatureAdditionalProperties: "additionalProperties",
+	tooladapter.FeatureMinimum:              "minimum",
+	tooladapter.FeatureMaximum:              "maximum",
+	tooladapter.FeatureMinLength:            "minLength",
+	tooladapter.FeatureMaxLength:            "maxLength",
+	tooladapter.FeatureEnum:                 "enum",
+	tooladapter.FeatureConst:                "const",
+	tooladapter.FeatureDefault:              "default",
+}
+
+// usesFeature reports whether the schema itself (not its children) uses f.
+func usesFeature(schema *tooladapter.JSONSchema, f tooladapter.SchemaFeature) bool {
+	switch f {
+	case tooladapter.FeatureRef:
+		return schema.Ref != ""
+	case tooladapter.FeatureDefs:
+		return len(schema.Defs) > 0
+	case tooladapter.FeatureAnyOf:
+		return len(schema.AnyOf) > 0
+	case tooladapter.FeatureOneOf:
+		return len(schema.OneOf) > 0
+	case tooladapter.FeatureAllOf:
+		return len(schema.AllOf) > 0
+	case tooladapter.FeatureNot:
+		return schema.Not != nil
+	case tooladapter.FeaturePattern:
+		return schema.Pattern != ""
+	case tooladapter.FeatureFormat:
+		return schema.Format != ""
+	case tooladapter.FeatureAdditionalProperties:
+		return schema.AdditionalProperties != nil
+	case tooladapter.FeatureMinimum:
+		return schema.Minimum != nil
+	case tooladapter.FeatureMaximum:
+		return schema.Maximum != nil
+	case tooladapter.FeatureMinLength:
+		return schema.MinLength != nil
+	case tooladapter.FeatureMaxLength:
+		return schema.MaxLength != nil
+	case tooladapter.FeatureEnum:
+		return len(schema.Enum) > 0
+	case tooladapter.FeatureConst:
+		return schema.Const != nil
+	case tooladapter.FeatureDefault:
+		return schema.Default != nil
+	}
+	return false
+}
+
+// featureLoss detects unsupported schema features for one tool.
+type featureLoss struct {
+	toolID  string
+	from    string
+	adapter tooladapter.Adapter
+}
+
+// detect appends a warning for every unsupported feature used by schema or
+// its subschemas. Subschemas are visited in a fixed order with map keys
+// sorted, so the result is deterministic.
+func (l featureLoss) detect(warnings []SchemaWarning, which, pointer string, schema *tooladapter.JSONSchema) []SchemaWarning {
 	if schema == nil {
-		return nil
-	}
-
-	featureUsage := map[tooladapter.SchemaFeature]bool{
-		tooladapter.FeatureRef:                  schema.Ref != "",
-		tooladapter.FeatureDefs:                 len(schema.Defs) > 0,
-		tooladapter.FeatureAnyOf:                len(schema.AnyOf) > 0,
-		tooladapter.FeatureOneOf:                len(schema.OneOf) > 0,
-		tooladapter.FeatureAllOf:                len(schema.AllOf) > 0,
-		tooladapter.FeatureNot:                  schema.Not != nil,
-		tooladapter.FeaturePattern:              schema.Pattern != "",
-		tooladapter.FeatureFormat:               schema.Format != "",
-		tooladapter.FeatureAdditionalProperties: schema.AdditionalProperties != nil,
-		tooladapter.FeatureMinimum:              schema.Minimum != nil,
-		tooladapter.FeatureMaximum:              schema.Maximum != nil,
-		tooladapter.FeatureMinLength:            schema.MinLength != nil,
-		tooladapter.FeatureMaxLength:            schema.MaxLength != nil,
-		tooladapter.FeatureEnum:                 len(schema.Enum) > 0,
-		tooladapter.FeatureConst:                schema.Const != nil,
-		tooladapter.FeatureDefault:              schema.Default != nil,
+		return warnings
 	}
 
-	var warnings []tooladapter.FeatureLossWarning
-	for feature, used := range featureUsage {
-		if used && !adapter.SupportsFeature(feature) {
-			warnings = append(warnings, tooladapter.FeatureLossWarning{
-				Feature:     feature,
-				FromAdapter: sourceName,
-				ToAdapter:   adapter.Name(),
+	for _, feature := range tooladapter.AllFeatures() {
+		if usesFeature(schema, feature) && !l.adapter.SupportsFeature(feature) {
+			warnings = append(warnings, SchemaWarning{
+				FeatureLossWarning: tooladapter.FeatureLossWarning{
+					Feature:     feature,
+					FromAdapter: l.from,
+					ToAdapter:   l.adapter.Name(),
+				},
+				ToolID:  l.toolID,
+				Schema:  which,
+				Pointer: pointer + "/" + escapePointer(featureKeywords[feature]),
 			})
 		}
 	}
 
-	if schema.Properties != nil {
-		for _, prop := range schema.Properties {
-			warnings = append(warnings, detectSchemaFeatureLoss(prop, sourceName, adapter)...)
-		}
+	for _, name := range sortedKeys(schema.Properties) {
+		warnings = l.detect(warnings, which, pointer+"/properties/"+escapePointer(name), schema.Properties[name])
 	}
-	if schema.Items != nil {
-		warnings = append(warnings, detectSchemaFeatureLoss(schema.Items, sourceName, adapter)...)
-	}
-	if schema.Defs != nil {
-		for _, def := range schema.Defs {
-			warnings = append(warnings, detectSchemaFeatureLoss(def, sourceName, adapter)...)
-		}
+	warnings = l.detect(warnings, which, pointer+"/items", schema.Items)
+	for _, name := range sortedKeys(schema.Defs) {
+		warnings = l.detect(warnings, which, pointer+"/$defs/"+escapePointer(name), schema.Defs[name])
 	}
-	for _, s := range schema.AnyOf {
-		warnings = append(warnings, detectSchemaFeatureLoss(s, sourceName, adapter)...)
+	for i, s := range schema.AnyOf {
+		warnings = l.detect(warnings, which, pointer+"/anyOf/"+strconv.Itoa(i), s)
 	}
-	for _, s := range schema.OneOf {
-		warnings = append(warnings, detectSchemaFeatureLoss(s, sourceName, adapter)...)
+	for i, s := range schema.OneOf {
+		warnings = l.detect(warnings, which, pointer+"/oneOf/"+strconv.Itoa(i), s)
 	}
-	for _, s := range schema.AllOf {
-		warnings = append(warnings, detectSchemaFeatureLoss(s, sourceName, adapter)...)
+	for i, s := range schema.AllOf {
+		warnings = l.detect(warnings, which, pointer+"/allOf/"+strconv.Itoa(i), s)
 	}
-	if schema.Not != nil {
-		warnings = append(warnings, detectSchemaFeatureLoss(schema.Not, sourceName, adapter)...)
+	return l.detect(warnings, which, pointer+"/not", schema.Not)
+}
+
+// sortedKeys returns the keys of a schema map in lexicographic order.
+func sortedKeys(m map[string]*tooladapter.JSONSchema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortSchemaWarnings orders warnings by tool ID, schema, pointer, feature
+// and action.
+func sortSchemaWarnings(warnings []SchemaWarning) {
+	sort.SliceStable(warnings, func(i, j int) bool {
+		a, b := warnings[i], warnings[j]
+		if a.ToolID != b.ToolID {
+			return a.ToolID < b.ToolID
+		}
+		if a.Schema != b.Schema {
+			return a.Schema < b.Schema
+		}
+		if a.Pointer != b.Pointer {
+			return a.Pointer < b.Pointer
+		}
+		if a.Feature != b.Feature {
+			return a.Feature < b.Feature
+		}
+		return a.Action < b.Action
+	})
+}
 
-	return warnings
+// dedupSchemaWarnings keeps the first warning per tool, feature and action.
+func dedupSchemaWarnings(warnings []SchemaWarning) []SchemaWarning {
+	type key struct {
+		toolID  string
+		feature tooladapter.SchemaFeature
+		action  LoweringAction
+	}
+	seen := make(map[key]bool, len(warnings))
+	out := warnings[:0]
+	for _, w := range warnings {
+		k := key{w.ToolID, w.Feature, w.Action}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, w)
+	}
+	return out
 }
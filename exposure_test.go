@@ -2,6 +2,8 @@ package toolset
 
 import (
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/jonwraymond/tooladapter"
@@ -552,3 +554,106 @@ func TestExposure_RefInNested(t *testing.T) {
 		}
 	})
 }
+
+func TestExposure_ExportWithSchemaWarnings(t *testing.T) {
+	newToolset := func() *Toolset {
+		ts := New("test")
+		ts.Add(&tooladapter.CanonicalTool{
+			Namespace: "ns",
+			Name:      "search",
+			InputSchema: &tooladapter.JSONSchema{
+				Type: "object",
+				Properties: map[string]*tooladapter.JSONSchema{
+					"query": {Type: "string", Pattern: "^q", Format: "text"},
+					"lang":  {Type: "string", Pattern: "^[a-z]{2}$"},
+					"a/b":   {Type: "string", Pattern: "x"},
+				},
+			},
+			OutputSchema: &tooladapter.JSONSchema{Type: "string", Pattern: "^ok"},
+		})
+		ts.Add(&tooladapter.CanonicalTool{
+			Namespace:   "ns",
+			Name:        "fetch",
+			InputSchema: &tooladapter.JSONSchema{Type: "string", Pattern: "^http"},
+		})
+		return ts
+	}
+	adapter := &mockAdapter{
+		name:              "mock",
+		supportedFeatures: map[tooladapter.SchemaFeature]bool{},
+	}
+
+	t.Run("locates and sorts warnings", func(t *testing.T) {
+		_, warnings, errs := NewExposure(newToolset(), adapter).ExportWithSchemaWarnings()
+		if len(errs) != 0 {
+			t.Fatalf("errs = %v", errs)
+		}
+		var got []string
+		for _, w := range warnings {
+			got = append(got, w.ToolID+" "+w.Schema+w.Pointer)
+		}
+		want := []string{
+			"ns:fetch input/pattern",
+			"ns:search input/properties/a~1b/pattern",
+			"ns:search input/properties/lang/pattern",
+			"ns:search input/properties/query/format",
+			"ns:search input/properties/query/pattern",
+			"ns:search output/pattern",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("warnings = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("order is stable across runs", func(t *testing.T) {
+		_, first, _ := NewExposure(newToolset(), adapter).ExportWithSchemaWarnings()
+		for i := 0; i < 20; i++ {
+			_, again, _ := NewExposure(newToolset(), adapter).ExportWithSchemaWarnings()
+			if !reflect.DeepEqual(first, again) {
+				t.Fatalf("run %d: warnings differ:\n%v\n%v", i, first, again)
+			}
+		}
+	})
+
+	t.Run("dedup keeps first warning per tool and feature", func(t *testing.T) {
+		_, warnings, _ := NewExposure(newToolset(), adapter).WithWarningDedup(true).ExportWithSchemaWarnings()
+		var got []string
+		for _, w := range warnings {
+			got = append(got, w.ToolID+" "+w.Feature.String()+" "+w.Schema+w.Pointer)
+		}
+		want := []string{
+			"ns:fetch pattern input/pattern",
+			"ns:search pattern input/properties/a~1b/pattern",
+			"ns:search format input/properties/query/format",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("warnings = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("ExportWithWarnings uses the same order", func(t *testing.T) {
+		exp := NewExposure(newToolset(), adapter)
+		_, located, _ := exp.ExportWithSchemaWarnings()
+		_, plain, _ := exp.ExportWithWarnings()
+		if len(plain) != len(located) {
+			t.Fatalf("len = %d, want %d", len(plain), len(located))
+		}
+		for i := range plain {
+			if plain[i] != located[i].FeatureLossWarning {
+				t.Errorf("warning[%d] = %v, want %v", i, plain[i], located[i].FeatureLossWarning)
+			}
+		}
+	})
+
+	t.Run("String includes location", func(t *testing.T) {
+		w := SchemaWarning{
+			FeatureLossWarning: tooladapter.FeatureLossWarning{Feature: tooladapter.FeaturePattern, FromAdapter: "mcp", ToAdapter: "openai"},
+			ToolID:             "ns:search",
+			Schema:             "input",
+			Pointer:            "/properties/query/pattern",
+		}
+		if got := w.String(); !strings.HasPrefix(got, "ns:search input/properties/query/pattern: ") {
+			t.Errorf("String() = %q", got)
+		}
+	})
+}
@@ -0,0 +1,531 @@
+package toolset
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// Expression is a parsed filter expression.
+//
+// Grammar:
+//
+//	expr      = and { "||" and }
+//	and       = unary { "&&" unary }
+//	unary     = "!" unary | "(" expr ")" | predicate
+//	predicate = field "==" string
+//	          | field "!=" string
+//	          | field [ "not" ] "in" list
+//	          | field "like" string
+//	          | field "matches" string
+//	          | listField "has" string
+//	          | listField "has" ( "any" | "all" ) list
+//	list      = "(" [ string { "," string } ] ")"
+//
+// String fields are namespace, name, id, category and source_format.
+// List fields are tags and scopes (RequiredScopes). Strings are double-quoted
+// Go string literals. "like" matches a glob and "matches" an anchored RE2
+// regular expression (see GlobIDs and RegexIDs). Example:
+//
+//	namespace in ("github","jira") && tags has "read" && !(category == "admin")
+//	id like "github:*" && !(name matches "delete_.*")
+type Expression struct {
+	src  string
+	root FilterFunc
+}
+
+// ParseExpression parses and compiles a filter expression.
+// Errors are returned as *ExprError with the offending position.
+func ParseExpression(src string) (*Expression, error) {
+	p := &exprParser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokEOF {
+		return nil, p.errorf(p.tok.pos, "empty expression")
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf(p.tok.pos, "unexpected %s", p.tok)
+	}
+	return &Expression{src: src, root: root}, nil
+}
+
+// CompileExpression parses src and returns the resulting filter.
+func CompileExpression(src string) (FilterFunc, error) {
+	e, err := ParseExpression(src)
+	if err != nil {
+		return nil, err
+	}
+	return e.Filter(), nil
+}
+
+// String returns the expression source.
+func (e *Expression) String() string { return e.src }
+
+// Filter returns the compiled filter. Nil tools never match.
+func (e *Expression) Filter() FilterFunc { return e.root }
+
+// ExprError is a filter expression parse error.
+type ExprError struct {
+	// Expr is the full expression source.
+	Expr string
+
+	// Offset is the byte offset of the error in Expr.
+	Offset int
+
+	// Line and Column are the 1-based position of the error.
+	Line   int
+	Column int
+
+	// Msg describes the problem.
+	Msg string
+}
+
+func (e *ExprError) Error() string {
+	return fmt.Sprintf("expression %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// stringFields maps expression field names to scalar tool attributes.
+var stringFields = map[string]func(*tooladapter.CanonicalTool) string{
+	"namespace":     func(t *tooladapter.CanonicalTool) string { return t.Namespace },
+	"name":          func(t *tooladapter.CanonicalTool) string { return t.Name },
+	"id":            func(t *tooladapter.CanonicalTool) string { return t.ID() },
+	"category":      func(t *tooladapter.CanonicalTool) string { return t.Category },
+	"source_format": func(t *tooladapter.CanonicalTool) string { return t.SourceFormat },
+}
+
+// listFields maps expression field names to list tool attributes.
+var listFields = map[string]func(*tooladapter.CanonicalTool) []string{
+	"tags":   func(t *tooladapter.CanonicalTool) []string { return t.Tags },
+	"scopes": func(t *tooladapter.CanonicalTool) []string { return t.RequiredScopes },
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokLParen
+	tokRParen
+	tokComma
+	tokAnd
+	tokOr
+	tokNot
+	tokEq
+	tokNeq
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string // identifier name or unquoted string value
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokIdent:
+		return strconv.Quote(t.text)
+	case tokString:
+		return "string " + strconv.Quote(t.text)
+	case tokLParen:
+		return `"("`
+	case tokRParen:
+		return `")"`
+	case tokComma:
+		return `","`
+	case tokAnd:
+		return `"&&"`
+	case tokOr:
+		return `"||"`
+	case tokNot:
+		return `"!"`
+	case tokEq:
+		return `"=="`
+	case tokNeq:
+		return `"!="`
+	default:
+		return "unknown token"
+	}
+}
+
+type exprParser struct {
+	src string
+	off int
+	tok token
+}
+
+func (p *exprParser) errorf(pos int, format string, args ...any) *ExprError {
+	line, col := 1, 1
+	for _, r := range p.src[:pos] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return &ExprError{Expr: p.src, Offset: pos, Line: line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// next advances to the next token.
+func (p *exprParser) next() error {
+	for p.off < len(p.src) {
+		r, size := utf8.DecodeRuneInString(p.src[p.off:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		p.off += size
+	}
+	start := p.off
+	if start >= len(p.src) {
+		p.tok = token{kind: tokEOF, pos: start}
+		return nil
+	}
+
+	two := ""
+	if start+2 <= len(p.src) {
+		two = p.src[start : start+2]
+	}
+	switch two {
+	case "&&":
+		p.tok, p.off = token{kind: tokAnd, pos: start}, start+2
+		return nil
+	case "||":
+		p.tok, p.off = token{kind: tokOr, pos: start}, start+2
+		return nil
+	case "==":
+		p.tok, p.off = token{kind: tokEq, pos: start}, start+2
+		return nil
+	case "!=":
+		p.tok, p.off = token{kind: tokNeq, pos: start}, start+2
+		return nil
+	}
+
+	switch c := p.src[start]; {
+	case c == '(':
+		p.tok, p.off = token{kind: tokLParen, pos: start}, start+1
+	case c == ')':
+		p.tok, p.off = token{kind: tokRParen, pos: start}, start+1
+	case c == ',':
+		p.tok, p.off = token{kind: tokComma, pos: start}, start+1
+	case c == '!':
+		p.tok, p.off = token{kind: tokNot, pos: start}, start+1
+	case c == '"':
+		return p.scanString()
+	case c == '_' || c < utf8.RuneSelf && unicode.IsLetter(rune(c)):
+		end := start
+		for end < len(p.src) && (p.src[end] == '_' || p.src[end] < utf8.RuneSelf && (unicode.IsLetter(rune(p.src[end])) || unicode.IsDigit(rune(p.src[end])))) {
+			end++
+		}
+		p.tok, p.off = token{kind: tokIdent, pos: start, text: p.src[start:end]}, end
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[start:])
+		return p.errorf(start, "unexpected character %q", r)
+	}
+	return nil
+}
+
+// scanString scans a double-quoted Go string literal.
+func (p *exprParser) scanString() error {
+	start := p.off
+	end := start + 1
+	for end < len(p.src) {
+		switch p.src[end] {
+		case '\\':
+			end += 2
+			continue
+		case '\n':
+			return p.errorf(start, "unterminated string")
+		case '"':
+			value, err := strconv.Unquote(p.src[start : end+1])
+			if err != nil {
+				return p.errorf(start, "invalid string %s", p.src[start:end+1])
+			}
+			p.tok, p.off = token{kind: tokString, pos: start, text: value}, end+1
+			return nil
+		}
+		end++
+	}
+	return p.errorf(start, "unterminated string")
+}
+
+// expect consumes a token of the given kind.
+func (p *exprParser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.tok
+	if tok.kind != kind {
+		return tok, p.errorf(tok.pos, "expected %s, found %s", what, tok)
+	}
+	return tok, p.next()
+}
+
+func (p *exprParser) parseOr() (FilterFunc, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	terms := []FilterFunc{left}
+	for p.tok.kind == tokOr {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, right)
+	}
+	if len(terms) == 1 {
+		return left, nil
+	}
+	return Or(terms...), nil
+}
+
+func (p *exprParser) parseAnd() (FilterFunc, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	terms := []FilterFunc{left}
+	for p.tok.kind == tokAnd {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, right)
+	}
+	if len(terms) == 1 {
+		return left, nil
+	}
+	return And(terms...), nil
+}
+
+func (p *exprParser) parseUnary() (FilterFunc, error) {
+	switch p.tok.kind {
+	case tokNot:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not(inner), nil
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokRParen, `")"`); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	case tokIdent:
+		return p.parsePredicate()
+	default:
+		return nil, p.errorf(p.tok.pos, "expected field name, found %s", p.tok)
+	}
+}
+
+func (p *exprParser) parsePredicate() (FilterFunc, error) {
+	field := p.tok
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if get, ok := stringFields[field.text]; ok {
+		return p.parseStringPredicate(field, get)
+	}
+	if get, ok := listFields[field.text]; ok {
+		return p.parseListPredicate(field, get)
+	}
+	return nil, p.errorf(field.pos, "unknown field %q (want namespace, name, id, category, source_format, tags or scopes)", field.text)
+}
+
+func (p *exprParser) parseStringPredicate(field token, get func(*tooladapter.CanonicalTool) string) (FilterFunc, error) {
+	op := p.tok
+	switch {
+	case op.kind == tokEq || op.kind == tokNeq:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		value, err := p.expect(tokString, "string")
+		if err != nil {
+			return nil, err
+		}
+		match := stringIn(get, value.text)
+		if op.kind == tokNeq {
+			return Not(match), nil
+		}
+		return match, nil
+	case op.kind == tokIdent && (op.text == "in" || op.text == "not"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if op.text == "not" {
+			if p.tok.kind != tokIdent || p.tok.text != "in" {
+				return nil, p.errorf(p.tok.pos, `expected "in" after "not", found %s`, p.tok)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		match := stringIn(get, values...)
+		if op.text == "not" {
+			return Not(match), nil
+		}
+		return match, nil
+	case op.kind == tokIdent && (op.text == "like" || op.text == "matches"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		value, err := p.expect(tokString, "pattern string")
+		if err != nil {
+			return nil, err
+		}
+		compile := compileGlobs
+		if op.text == "matches" {
+			compile = compileRegexps
+		}
+		set, err := compile([]string{value.text})
+		if err != nil {
+			return nil, p.errorf(value.pos, "%v", err)
+		}
+		return func(t *tooladapter.CanonicalTool) bool {
+			return t != nil && set.match(get(t))
+		}, nil
+	default:
+		return nil, p.errorf(op.pos, `expected "==", "!=", "in", "not in", "like" or "matches" after %q, found %s`, field.text, op)
+	}
+}
+
+func (p *exprParser) parseListPredicate(field token, get func(*tooladapter.CanonicalTool) []string) (FilterFunc, error) {
+	op := p.tok
+	if op.kind != tokIdent || op.text != "has" {
+		return nil, p.errorf(op.pos, `expected "has" after %q, found %s`, field.text, op)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	switch {
+	case p.tok.kind == tokString:
+		value := p.tok.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return listHasAny(get, value), nil
+	case p.tok.kind == tokIdent && (p.tok.text == "any" || p.tok.text == "all"):
+		quantifier := p.tok.text
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		if quantifier == "all" {
+			return listHasAll(get, values...), nil
+		}
+		return listHasAny(get, values...), nil
+	default:
+		return nil, p.errorf(p.tok.pos, `expected string, "any" or "all" after "has", found %s`, p.tok)
+	}
+}
+
+// parseList parses a parenthesized, comma-separated list of strings.
+func (p *exprParser) parseList() ([]string, error) {
+	if _, err := p.expect(tokLParen, `"("`); err != nil {
+		return nil, err
+	}
+	var values []string
+	if p.tok.kind == tokRParen {
+		return values, p.next()
+	}
+	for {
+		value, err := p.expect(tokString, "string")
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value.text)
+		if p.tok.kind != tokComma {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect(tokRParen, `"," or ")"`); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// stringIn matches tools whose attribute equals any of the values.
+func stringIn(get func(*tooladapter.CanonicalTool) string, values ...string) FilterFunc {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return func(t *tooladapter.CanonicalTool) bool {
+		if t == nil {
+			return false
+		}
+		return set[get(t)]
+	}
+}
+
+// listHasAny matches tools whose list attribute contains any of the values.
+func listHasAny(get func(*tooladapter.CanonicalTool) []string, values ...string) FilterFunc {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return func(t *tooladapter.CanonicalTool) bool {
+		if t == nil {
+			return false
+		}
+		for _, v := range get(t) {
+			if set[v] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// listHasAll matches tools whose list attribute contains all of the values.
+func listHasAll(get func(*tooladapter.CanonicalTool) []string, values ...string) FilterFunc {
+	return func(t *tooladapter.CanonicalTool) bool {
+		if t == nil {
+			return false
+		}
+		have := make(map[string]bool, len(get(t)))
+		for _, v := range get(t) {
+			have[v] = true
+		}
+		for _, v := range values {
+			if !have[v] {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,131 @@
+package toolset
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func TestParseExpression(t *testing.T) {
+	github := &tooladapter.CanonicalTool{Namespace: "github", Name: "list-repos", Tags: []string{"read"}, RequiredScopes: []string{"repo"}, SourceFormat: "mcp"}
+	jiraAdmin := &tooladapter.CanonicalTool{Namespace: "jira", Name: "delete", Tags: []string{"read", "write"}, Category: "admin"}
+	slack := &tooladapter.CanonicalTool{Name: "send", Tags: []string{"write"}}
+
+	tests := []struct {
+		expr string
+		want []bool // github, jiraAdmin, slack
+	}{
+		{`namespace in ("github","jira") && tags has "read" && !(category == "admin")`, []bool{true, false, false}},
+		{`namespace == "github"`, []bool{true, false, false}},
+		{`namespace != "github"`, []bool{false, true, true}},
+		{`namespace not in ("github", "jira")`, []bool{false, false, true}},
+		{`name == "send" || category == "admin"`, []bool{false, true, true}},
+		{`id == "github:list-repos"`, []bool{true, false, false}},
+		{`id == "send"`, []bool{false, false, true}},
+		{`tags has any ("write", "none")`, []bool{false, true, true}},
+		{`tags has all ("read", "write")`, []bool{false, true, false}},
+		{`scopes has "repo"`, []bool{true, false, false}},
+		{`source_format == "mcp"`, []bool{true, false, false}},
+		{`!tags has "write"`, []bool{true, false, false}},
+		{`tags has "read" || tags has "write" && namespace == "jira"`, []bool{true, true, false}},
+		{`(tags has "read" || tags has "write") && namespace == ""`, []bool{false, false, true}},
+		{`namespace in ()`, []bool{false, false, false}},
+		{`id like "github:*"`, []bool{true, false, false}},
+		{`name like "?e*"`, []bool{false, true, true}},
+		{`name matches "(delete|send)"`, []bool{false, true, true}},
+		{`name matches "end"`, []bool{false, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			fn, err := CompileExpression(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileExpression() error = %v", err)
+			}
+			for i, tool := range []*tooladapter.CanonicalTool{github, jiraAdmin, slack} {
+				if got := fn(tool); got != tt.want[i] {
+					t.Errorf("match %s = %v, want %v", tool.ID(), got, tt.want[i])
+				}
+			}
+			if fn(nil) {
+				t.Error("nil tool should not match")
+			}
+		})
+	}
+}
+
+func TestParseExpression_Errors(t *testing.T) {
+	tests := []struct {
+		expr   string
+		line   int
+		column int
+	}{
+		{``, 1, 1},
+		{`   `, 1, 4},
+		{`owner == "x"`, 1, 1},
+		{`namespace = "x"`, 1, 11},
+		{`namespace == x`, 1, 14},
+		{`namespace == "x" &&`, 1, 20},
+		{`namespace in ("a" "b")`, 1, 19},
+		{`namespace not ("a")`, 1, 15},
+		{`tags == "a"`, 1, 6},
+		{`tags has some ("a")`, 1, 10},
+		{`(namespace == "a"`, 1, 18},
+		{`namespace == "a")`, 1, 17},
+		{`namespace == "unterminated`, 1, 14},
+		{"namespace == \"a\" &&\n  name # \"b\"", 2, 8},
+		{`name like "[a"`, 1, 11},
+		{`name matches "(a"`, 1, 14},
+		{`name like ("a")`, 1, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseExpression(tt.expr)
+			var exprErr *ExprError
+			if !errors.As(err, &exprErr) {
+				t.Fatalf("ParseExpression() error = %v, want *ExprError", err)
+			}
+			if exprErr.Line != tt.line || exprErr.Column != tt.column {
+				t.Errorf("position = %d:%d, want %d:%d (%v)", exprErr.Line, exprErr.Column, tt.line, tt.column, err)
+			}
+		})
+	}
+}
+
+func TestExpression_String(t *testing.T) {
+	src := `tags has "read"`
+	e, err := ParseExpression(src)
+	if err != nil {
+		t.Fatalf("ParseExpression() error = %v", err)
+	}
+	if e.String() != src {
+		t.Errorf("String() = %q, want %q", e.String(), src)
+	}
+}
+
+func TestBuilder_WithExpression(t *testing.T) {
+	t.Run("filters with expression", func(t *testing.T) {
+		ts, err := NewBuilder("test").
+			FromTools(journeyTools()).
+			WithExpression(`namespace == "mcp" && !(tags has "danger")`).
+			Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		ids := ts.IDs()
+		if len(ids) != 2 || ids[0] != "mcp:fetch" || ids[1] != "mcp:search" {
+			t.Errorf("IDs() = %v, want [mcp:fetch mcp:search]", ids)
+		}
+	})
+
+	t.Run("parse error returned by Build", func(t *testing.T) {
+		_, err := NewBuilder("test").
+			FromTools(journeyTools()).
+			WithExpression(`namespace ==`).
+			Build()
+		var exprErr *ExprError
+		if !errors.As(err, &exprErr) {
+			t.Errorf("Build() error = %v, want *ExprError", err)
+		}
+	})
+}
@@ -117,3 +117,65 @@ func DenyIDs(ids ...string) FilterFunc {
 		return !set[t.ID()]
 	}
 }
+
+// And returns a filter matching tools that match ALL of the filters.
+// With no filters it matches every non-nil tool. Nil filters never match.
+func And(filters ...FilterFunc) FilterFunc {
+	return func(t *tooladapter.CanonicalTool) bool {
+		if t == nil {
+			return false
+		}
+		for _, fn := range filters {
+			if fn == nil || !fn(t) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a filter matching tools that match ANY of the filters.
+// With no filters it matches nothing. Nil filters never match.
+func Or(filters ...FilterFunc) FilterFunc {
+	return func(t *tooladapter.CanonicalTool) bool {
+		if t == nil {
+			return false
+		}
+		for _, fn := range filters {
+			if fn != nil && fn(t) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a filter matching tools that do not match fn.
+// A nil fn never matches, so Not(nil) matches every non-nil tool.
+func Not(fn FilterFunc) FilterFunc {
+	return func(t *tooladapter.CanonicalTool) bool {
+		if t == nil {
+			return false
+		}
+		return fn == nil || !fn(t)
+	}
+}
+
+// None returns a filter matching tools that match NONE of the filters.
+func None(filters ...FilterFunc) FilterFunc {
+	return Not(Or(filters...))
+}
+
+// Always returns a filter matching every non-nil tool.
+func Always() FilterFunc {
+	return func(t *tooladapter.CanonicalTool) bool {
+		return t != nil
+	}
+}
+
+// Never returns a filter matching no tools.
+func Never() FilterFunc {
+	return func(*tooladapter.CanonicalTool) bool {
+		return false
+	}
+}
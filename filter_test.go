@@ -320,3 +320,75 @@ func TestDenyIDs(t *testing.T) {
 		}
 	})
 }
+
+func TestCombinators(t *testing.T) {
+	github := makeTool("github", "list-repos", []string{"read"})
+	safe := makeTool("jira", "search", []string{"safe"})
+	admin := &tooladapter.CanonicalTool{Namespace: "github", Name: "delete-repo", Tags: []string{"safe"}, Category: "admin"}
+	other := makeTool("slack", "send", nil)
+
+	// namespace github OR tagged safe, but NOT category admin
+	filter := And(
+		Or(NamespaceFilter("github"), TagsAny("safe")),
+		Not(CategoryFilter("admin")),
+	)
+
+	tests := []struct {
+		tool *tooladapter.CanonicalTool
+		want bool
+	}{
+		{github, true},
+		{safe, true},
+		{admin, false},
+		{other, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := filter(tt.tool); got != tt.want {
+			t.Errorf("filter(%v) = %v, want %v", tt.tool, got, tt.want)
+		}
+	}
+
+	t.Run("empty groups", func(t *testing.T) {
+		if !And()(other) {
+			t.Error("And() should match every tool")
+		}
+		if Or()(other) {
+			t.Error("Or() should match nothing")
+		}
+		if !None()(other) {
+			t.Error("None() should match every tool")
+		}
+	})
+
+	t.Run("None excludes any match", func(t *testing.T) {
+		filter := None(NamespaceFilter("github"), TagsAny("safe"))
+		if filter(github) || filter(safe) {
+			t.Error("None should exclude tools matching any filter")
+		}
+		if !filter(other) {
+			t.Error("None should include tools matching no filter")
+		}
+	})
+
+	t.Run("nil filters never match", func(t *testing.T) {
+		if And(nil)(other) {
+			t.Error("And(nil) should not match")
+		}
+		if Or(nil)(other) {
+			t.Error("Or(nil) should not match")
+		}
+		if !Not(nil)(other) {
+			t.Error("Not(nil) should match")
+		}
+	})
+
+	t.Run("Always and Never", func(t *testing.T) {
+		if !Always()(other) || Always()(nil) {
+			t.Error("Always should match every non-nil tool")
+		}
+		if Never()(other) {
+			t.Error("Never should match nothing")
+		}
+	})
+}
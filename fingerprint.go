@@ -0,0 +1,350 @@
+package toolset
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// fingerprintDomain prefixes the canonical form before hashing, so that
+// fingerprints can never collide with hashes of other data, and so that a
+// future canonical form can change the prefix instead of silently changing
+// existing fingerprints.
+const fingerprintDomain = "toolset/fingerprint/v1\x00"
+
+// CanonicalJSON returns the canonical serialization of the fingerprinted
+// fields of t, or nil if t is nil.
+//
+// The canonical form (version 1) is a JSON object with exactly these keys:
+// category, description, input_schema, name, namespace, output_schema,
+// required_scopes and tags. Schemas are encoded with JSON Schema keywords
+// (as by JSONSchema.ToMap), or null when absent; "required" lists are sorted.
+// Tags and required scopes are sorted and de-duplicated.
+//
+// Encoding rules: object keys are sorted bytewise and there is no
+// whitespace; strings are escaped as by encoding/json without HTML escaping.
+// Integers are written exactly, and integral floats with magnitude below
+// 1e21 as integers, so 1, int64(1) and 1.0 encode alike; other finite
+// numbers use strconv's shortest 'g' form. Structs encode as objects of
+// their exported fields, named by json tags.
+//
+// Values JSON cannot represent are written as tokens that no JSON value
+// produces, so they never collide with strings: NaN, Infinity and
+// -Infinity; maps with non-string keys as objects whose keys are in
+// canonical form (e.g. {1:"a"}); complex numbers as <complex re im>; and
+// funcs, channels and unsafe pointers as <func>, <chan> and
+// <unsafe.Pointer>.
+//
+// Fields not listed (Version, Timeout, SourceFormat, SourceMeta) do not
+// affect the fingerprint.
+func CanonicalJSON(t *tooladapter.CanonicalTool) []byte {
+	if t == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	writeCanonical(&buf, map[string]any{
+		"namespace":       t.Namespace,
+		"name":            t.Name,
+		"description":     t.Description,
+		"category":        t.Category,
+		"tags":            canonicalSet(t.Tags),
+		"required_scopes": canonicalSet(t.RequiredScopes),
+		"input_schema":    canonicalSchema(t.InputSchema),
+		"output_schema":   canonicalSchema(t.OutputSchema),
+	})
+	return buf.Bytes()
+}
+
+// Fingerprint returns the content fingerprint of t as "sha256:" followed by
+// 64 hex digits: the SHA-256 of "toolset/fingerprint/v1", a zero byte and
+// CanonicalJSON(t). It returns "" for a nil tool.
+func Fingerprint(t *tooladapter.CanonicalTool) string {
+	if t == nil {
+		return ""
+	}
+	sum := toolDigest(t)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// toolDigest is the raw SHA-256 behind Fingerprint.
+func toolDigest(t *tooladapter.CanonicalTool) [sha256.Size]byte {
+	return sha256.Sum256(append([]byte(fingerprintDomain), CanonicalJSON(t)...))
+}
+
+// Fingerprint returns the fingerprint of the whole toolset: the root of a
+// Merkle tree over its tool fingerprints (see Snapshot.Fingerprint).
+func (ts *Toolset) Fingerprint() string {
+	return ts.Snapshot().Fingerprint()
+}
+
+// Fingerprint returns the Merkle root of the snapshot's tools as "sha256:"
+// followed by 64 hex digits. It is computed once per snapshot.
+//
+// The tree follows RFC 6962: leaves are the tool digests in tool ID order,
+// a leaf hash is SHA-256(0x00 || digest), an interior node is
+// SHA-256(0x01 || left || right), and a list of n > 1 leaves is split after
+// the largest power of two smaller than n. An empty toolset has the root
+// SHA-256(""). The root depends only on tool content, not on insertion
+// order or toolset name.
+func (s *Snapshot) Fingerprint() string {
+	s.fingerprintOnce.Do(func() {
+		leaves := make([][sha256.Size]byte, len(s.tools))
+		for i, t := range s.tools {
+			leaves[i] = toolDigest(t)
+		}
+		root := merkleRoot(leaves)
+		s.fingerprint = "sha256:" + hex.EncodeToString(root[:])
+	})
+	return s.fingerprint
+}
+
+// merkleRoot computes the RFC 6962 Merkle tree hash of leaves.
+func merkleRoot(leaves [][sha256.Size]byte) [sha256.Size]byte {
+	switch len(leaves) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return sha256.Sum256(append([]byte{0}, leaves[0][:]...))
+	}
+	split := 1
+	for split*2 < len(leaves) {
+		split *= 2
+	}
+	left, right := merkleRoot(leaves[:split]), merkleRoot(leaves[split:])
+	node := make([]byte, 0, 1+2*sha256.Size)
+	node = append(append(append(node, 1), left[:]...), right[:]...)
+	return sha256.Sum256(node)
+}
+
+// canonicalSet returns values sorted and de-duplicated, never nil.
+func canonicalSet(values []string) []string {
+	if set := sortedSet(values); set != nil {
+		return set
+	}
+	return []string{}
+}
+
+// canonicalSchema returns the keyword map of s with every "required" list
+// sorted, or nil.
+func canonicalSchema(s *tooladapter.JSONSchema) any {
+	if s == nil {
+		return nil
+	}
+	s = s.DeepCopy()
+	sortRequired(s)
+	return s.ToMap()
+}
+
+// sortRequired sorts the required lists of s and its subschemas in place.
+func sortRequired(s *tooladapter.JSONSchema) {
+	if s == nil {
+		return
+	}
+	sort.Strings(s.Required)
+	for _, sub := range s.Properties {
+		sortRequired(sub)
+	}
+	for _, sub := range s.Defs {
+		sortRequired(sub)
+	}
+	sortRequired(s.Items)
+	sortRequired(s.Not)
+	for _, list := range [][]*tooladapter.JSONSchema{s.AnyOf, s.OneOf, s.AllOf} {
+		for _, sub := range list {
+			sortRequired(sub)
+		}
+	}
+}
+
+// writeCanonical appends the canonical JSON encoding of v to buf.
+func writeCanonical(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case string:
+		writeCanonicalString(buf, v)
+	case json.Number:
+		writeCanonicalJSONNumber(buf, v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonicalString(buf, k)
+			buf.WriteByte(':')
+			writeCanonical(buf, v[k])
+		}
+		buf.WriteByte('}')
+	default:
+		writeCanonicalValue(buf, reflect.ValueOf(v))
+	}
+}
+
+// writeCanonicalValue encodes values of other types by kind.
+func writeCanonicalValue(buf *bytes.Buffer, rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(rv.Bool()))
+	case reflect.String:
+		writeCanonicalString(buf, rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buf.WriteString(strconv.FormatInt(rv.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buf.WriteString(strconv.FormatUint(rv.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		writeCanonicalNumber(buf, rv.Float())
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			buf.WriteString("null")
+			return
+		}
+		buf.WriteByte('[')
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeCanonical(buf, rv.Index(i).Interface())
+		}
+		buf.WriteByte(']')
+	case reflect.Map:
+		if rv.IsNil() {
+			buf.WriteString("null")
+			return
+		}
+		writeCanonicalMap(buf, rv)
+	case reflect.Struct:
+		writeCanonicalStruct(buf, rv)
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			buf.WriteString("null")
+			return
+		}
+		writeCanonical(buf, rv.Elem().Interface())
+	case reflect.Complex64, reflect.Complex128:
+		c := rv.Complex()
+		buf.WriteString("<complex ")
+		writeCanonicalNumber(buf, real(c))
+		buf.WriteByte(' ')
+		writeCanonicalNumber(buf, imag(c))
+		buf.WriteByte('>')
+	default: // func, chan, unsafe pointer, invalid
+		buf.WriteString("<" + rv.Kind().String() + ">")
+	}
+}
+
+// writeCanonicalMap encodes a map. String keys are written as strings and
+// sorted bytewise; other keys are written in their canonical encoding and
+// sorted by it, so they never collide with string keys.
+func writeCanonicalMap(buf *bytes.Buffer, rv reflect.Value) {
+	type entry struct {
+		key   []byte
+		value reflect.Value
+		str   string
+	}
+	stringKeys := rv.Type().Key().Kind() == reflect.String
+	entries := make([]entry, 0, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		var key bytes.Buffer
+		if stringKeys {
+			writeCanonicalString(&key, iter.Key().String())
+		} else {
+			writeCanonicalValue(&key, iter.Key())
+		}
+		e := entry{key: key.Bytes(), value: iter.Value()}
+		if stringKeys {
+			e.str = iter.Key().String()
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if stringKeys {
+			return entries[i].str < entries[j].str
+		}
+		return bytes.Compare(entries[i].key, entries[j].key) < 0
+	})
+	buf.WriteByte('{')
+	for i, e := range entries {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(e.key)
+		buf.WriteByte(':')
+		writeCanonicalValue(buf, e.value)
+	}
+	buf.WriteByte('}')
+}
+
+// writeCanonicalStruct encodes the exported fields of a struct as an
+// object, named by their json tags as encoding/json would.
+func writeCanonicalStruct(buf *bytes.Buffer, rv reflect.Value) {
+	fields := make(map[string]any)
+	for i := 0; i < rv.NumField(); i++ {
+		f := rv.Type().Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		fields[name] = rv.Field(i).Interface()
+	}
+	writeCanonical(buf, fields)
+}
+
+// writeCanonicalString appends s as a JSON string without HTML escaping.
+func writeCanonicalString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)           // strings always encode
+	buf.Truncate(buf.Len() - 1) // drop the newline Encode appends
+}
+
+// writeCanonicalJSONNumber appends n, keeping integers exact.
+func writeCanonicalJSONNumber(buf *bytes.Buffer, n json.Number) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		buf.WriteString(strconv.FormatInt(i, 10))
+	} else if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		buf.WriteString(strconv.FormatUint(u, 10))
+	} else if f, err := n.Float64(); err == nil {
+		writeCanonicalNumber(buf, f)
+	} else {
+		buf.WriteString("<number ")
+		buf.WriteString(string(n))
+		buf.WriteByte('>')
+	}
+}
+
+// writeCanonicalNumber appends f in canonical number form.
+func writeCanonicalNumber(buf *bytes.Buffer, f float64) {
+	switch {
+	case math.IsNaN(f):
+		buf.WriteString("NaN")
+	case math.IsInf(f, 1):
+		buf.WriteString("Infinity")
+	case math.IsInf(f, -1):
+		buf.WriteString("-Infinity")
+	case f == math.Trunc(f) && math.Abs(f) < 1e21:
+		buf.WriteString(strconv.FormatFloat(f, 'f', -1, 64))
+	default:
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+}
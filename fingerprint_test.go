@@ -0,0 +1,177 @@
+package toolset
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func fingerprintTool() *tooladapter.CanonicalTool {
+	limit := 10.0
+	return &tooladapter.CanonicalTool{
+		Namespace:      "github",
+		Name:           "search",
+		Description:    "Search <code>",
+		Category:       "read",
+		Tags:           []string{"search", "code", "search"},
+		RequiredScopes: []string{"repo:read"},
+		InputSchema: &tooladapter.JSONSchema{
+			Type:     "object",
+			Required: []string{"q", "limit"},
+			Properties: map[string]*tooladapter.JSONSchema{
+				"q":     {Type: "string"},
+				"limit": {Type: "integer", Maximum: &limit, Enum: []any{1, 2.5}},
+			},
+		},
+	}
+}
+
+func TestCanonicalJSON(t *testing.T) {
+	got := string(CanonicalJSON(fingerprintTool()))
+	want := `{"category":"read","description":"Search <code>","input_schema":{"properties":{"limit":{"enum":[1,2.5],"maximum":10,"type":"integer"},"q":{"type":"string"}},"required":["limit","q"],"type":"object"},"name":"search","namespace":"github","output_schema":null,"required_scopes":["repo:read"],"tags":["code","search"]}`
+	if got != want {
+		t.Errorf("CanonicalJSON() =\n%s\nwant\n%s", got, want)
+	}
+	if CanonicalJSON(nil) != nil {
+		t.Error("CanonicalJSON(nil) should be nil")
+	}
+}
+
+func TestCanonicalJSON_Values(t *testing.T) {
+	encode := func(v any) string {
+		tool := makeTool("ns", "a", nil)
+		tool.InputSchema = &tooladapter.JSONSchema{Default: v}
+		return string(CanonicalJSON(tool))
+	}
+	type point struct {
+		X    int `json:"x"`
+		Next *point
+		skip int
+	}
+
+	distinct := map[string][2]any{
+		"NaN and string":         {math.NaN(), "NaN"},
+		"Inf and string":         {math.Inf(1), "+Inf"},
+		"large integers":         {int64(1<<53 + 1), int64(1 << 53)},
+		"large unsigned":         {uint64(1<<64 - 1), uint64(1<<64 - 2)},
+		"number and json.Number": {json.Number("9007199254740993"), float64(1 << 53)},
+		"int and string keys":    {map[any]any{1: "a"}, map[string]any{"1": "a"}},
+		"func and string":        {func() {}, "<func>"},
+	}
+	for name, pair := range distinct {
+		if a, b := encode(pair[0]), encode(pair[1]); a == b {
+			t.Errorf("%s: both encode as %s", name, a)
+		}
+	}
+
+	same := map[string][2]any{
+		"int and float":       {int64(3), 3.0},
+		"json.Number":         {json.Number("9007199254740993"), uint64(9007199254740993)},
+		"pointers to structs": {&point{X: 1, Next: &point{X: 2}}, &point{X: 1, Next: &point{X: 2}}},
+		"struct and map":      {point{X: 1, skip: 2}, map[string]any{"x": 1, "Next": nil}},
+		"NaN":                 {math.NaN(), float32(math.NaN())},
+		"mixed key maps":      {map[any]any{2: "b", "a": 1, true: nil}, map[any]any{true: nil, "a": 1, 2: "b"}},
+	}
+	for name, pair := range same {
+		if a, b := encode(pair[0]), encode(pair[1]); a != b {
+			t.Errorf("%s: %s != %s", name, a, b)
+		}
+	}
+
+	if got := encode(map[any]any{1: math.Inf(-1), "1": []any{math.NaN()}}); !strings.Contains(got, `"default":{"1":[NaN],1:-Infinity}`) {
+		t.Errorf("CanonicalJSON() = %s", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	base := Fingerprint(fingerprintTool())
+	if !strings.HasPrefix(base, "sha256:") || len(base) != len("sha256:")+64 {
+		t.Fatalf("Fingerprint() = %q", base)
+	}
+	// Pinned: fingerprints must be reproducible across library versions.
+	if want := "sha256:1d2a75728ccbe9824264eabedbf29f34f07288e178c6618b85e43458d8fba3e2"; base != want {
+		t.Errorf("Fingerprint() = %s, want %s", base, want)
+	}
+
+	same := []func(*tooladapter.CanonicalTool){
+		func(t *tooladapter.CanonicalTool) { t.Tags = []string{"code", "search"} },
+		func(t *tooladapter.CanonicalTool) { t.InputSchema.Required = []string{"limit", "q"} },
+		func(t *tooladapter.CanonicalTool) { t.Version = "2.0.0" },
+		func(t *tooladapter.CanonicalTool) { t.SourceMeta = map[string]any{"title": "x"} },
+		func(t *tooladapter.CanonicalTool) {
+			t.InputSchema.Properties["limit"].Enum = []any{int64(1), float32(2.5)}
+		},
+	}
+	for i, mutate := range same {
+		tool := fingerprintTool()
+		mutate(tool)
+		if got := Fingerprint(tool); got != base {
+			t.Errorf("same[%d]: fingerprint changed", i)
+		}
+	}
+
+	different := map[string]func(*tooladapter.CanonicalTool){
+		"namespace":     func(t *tooladapter.CanonicalTool) { t.Namespace = "gitlab" },
+		"name":          func(t *tooladapter.CanonicalTool) { t.Name = "find" },
+		"description":   func(t *tooladapter.CanonicalTool) { t.Description = "Search" },
+		"category":      func(t *tooladapter.CanonicalTool) { t.Category = "" },
+		"tags":          func(t *tooladapter.CanonicalTool) { t.Tags = nil },
+		"scopes":        func(t *tooladapter.CanonicalTool) { t.RequiredScopes = []string{"repo"} },
+		"input schema":  func(t *tooladapter.CanonicalTool) { t.InputSchema.Properties["q"].MinLength = new(int) },
+		"output schema": func(t *tooladapter.CanonicalTool) { t.OutputSchema = &tooladapter.JSONSchema{} },
+		"enum order":    func(t *tooladapter.CanonicalTool) { t.InputSchema.Properties["limit"].Enum = []any{2.5, 1} },
+	}
+	for name, mutate := range different {
+		tool := fingerprintTool()
+		mutate(tool)
+		if Fingerprint(tool) == base {
+			t.Errorf("%s: fingerprint unchanged", name)
+		}
+	}
+
+	if Fingerprint(nil) != "" {
+		t.Error("Fingerprint(nil) should be empty")
+	}
+}
+
+func TestToolset_Fingerprint(t *testing.T) {
+	build := func(name string, names ...string) *Toolset {
+		ts := New(name)
+		for _, n := range names {
+			ts.Add(makeTool("ns", n, nil))
+		}
+		return ts
+	}
+
+	a, b := build("a", "x", "y", "z"), build("b", "z", "x", "y")
+	if a.Fingerprint() != b.Fingerprint() {
+		t.Error("fingerprint depends on insertion order or name")
+	}
+	if a.Fingerprint() == build("a", "x", "y").Fingerprint() {
+		t.Error("fingerprint unchanged after removing a tool")
+	}
+
+	// Known roots pin the tree shape (RFC 6962).
+	if got := New("empty").Fingerprint(); got != "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("empty root = %s", got)
+	}
+	sizes := map[string]bool{}
+	for n := 1; n <= 6; n++ {
+		names := []string{"a", "b", "c", "d", "e", "f"}[:n]
+		fp := build("t", names...).Fingerprint()
+		if sizes[fp] {
+			t.Errorf("duplicate root for %d tools", n)
+		}
+		sizes[fp] = true
+	}
+
+	one := build("one", "x")
+	leaf := toolDigest(makeTool("ns", "x", nil))
+	if want := merkleRoot([][32]byte{leaf}); one.Fingerprint() != "sha256:"+hex.EncodeToString(want[:]) {
+		t.Error("single-tool root should be the leaf hash")
+	}
+}
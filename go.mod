@@ -3,3 +3,5 @@ module github.com/jonwraymond/toolset
 go 1.24.4
 
 require github.com/jonwraymond/tooladapter v0.2.0
+
+require go.yaml.in/yaml/v3 v3.0.4
@@ -0,0 +1,240 @@
+package toolset
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// WatchableRegistry is a Registry that notifies watchers when its tools change.
+//
+// Contract:
+// - Concurrency: Watch and the returned stop function must be safe for concurrent use.
+// - Delivery: fn is called after Tools reflects a change; notifications may be coalesced.
+// - Blocking: fn may run on the registry's goroutine and should return promptly.
+// - Stop: stop is idempotent; fn may still be called once if a notification is in flight.
+// - Nil handling: Watch(nil) must not panic and may return a no-op stop function.
+type WatchableRegistry interface {
+	Registry
+	Watch(fn func()) (stop func())
+}
+
+// LiveChange describes a rebuild of a LiveToolset.
+// ID slices are sorted lexicographically.
+type LiveChange struct {
+	// Toolset is the toolset published by the rebuild.
+	Toolset *Toolset
+
+	// Added lists tools that were not in the previous toolset.
+	Added []string
+
+	// Removed lists tools that are no longer present.
+	Removed []string
+
+	// Changed lists tools whose definition changed.
+	Changed []string
+
+	// Err is set when the rebuild failed; Toolset is then the previous toolset.
+	Err error
+}
+
+// Empty reports whether the change carries no differences and no error.
+func (c LiveChange) Empty() bool {
+	return c.Err == nil && len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// LiveToolset keeps a Toolset in sync with the Builder's watchable sources.
+//
+// Every change notification re-runs the Builder's filters and policy and
+// atomically publishes the result. Published toolsets are replaced, not
+// mutated: treat them as read-only snapshots. The Builder must not be
+// modified after NewLiveToolset is called.
+type LiveToolset struct {
+	builder *Builder
+	current atomic.Pointer[Toolset]
+
+	buildMu sync.Mutex // serializes rebuilds and delivery order
+
+	subMu  sync.Mutex
+	subs   map[uint64]func(LiveChange)
+	nextID uint64
+
+	stopOnce sync.Once
+	stops    []func()
+}
+
+// NewLiveToolset starts watching every WatchableRegistry among the
+// Builder's sources, including ContextRegistry sources that provide the
+// same Watch method, and then builds the initial toolset. Watching first
+// means no change is lost between the initial build and registration. It
+// returns an error if the initial build fails or no source is watchable.
+func NewLiveToolset(b *Builder) (*LiveToolset, error) {
+	watchable := watchableSources(b)
+	if len(watchable) == 0 {
+		return nil, errors.New("no watchable source: use a WatchableRegistry with FromRegistry or AddSource")
+	}
+
+	l := &LiveToolset{builder: b, subs: make(map[uint64]func(LiveChange))}
+	for _, w := range watchable {
+		l.stops = append(l.stops, w.Watch(func() { _, _ = l.Refresh() }))
+	}
+	ts, err := b.Build()
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	// A notification during the build has already published a newer
+	// toolset; keep it.
+	l.buildMu.Lock()
+	if l.current.Load() == nil {
+		l.current.Store(ts)
+	}
+	l.buildMu.Unlock()
+	return l, nil
+}
+
+// Toolset returns the most recently published toolset.
+func (l *LiveToolset) Toolset() *Toolset {
+	return l.current.Load()
+}
+
+// Refresh rebuilds immediately, publishes the result and notifies
+// subscribers if anything changed. On error the previous toolset is kept.
+func (l *LiveToolset) Refresh() (LiveChange, error) {
+	l.buildMu.Lock()
+	defer l.buildMu.Unlock()
+
+	prev := l.current.Load()
+	next, err := l.builder.Build()
+	if err != nil {
+		change := LiveChange{Toolset: prev, Err: err}
+		l.notify(change)
+		return change, err
+	}
+
+	if prev == nil { // notified before the initial build was published
+		l.current.Store(next)
+		return LiveChange{Toolset: next}, nil
+	}
+	next.inheritCursorKey(prev)
+	change := diffLive(prev, next)
+	l.current.Store(next)
+	if !change.Empty() {
+		l.notify(change)
+	}
+	return change, nil
+}
+
+// Subscribe registers fn to receive every non-empty LiveChange.
+// Changes are delivered in order on the goroutine that triggered the rebuild.
+// fn must not call Refresh. The returned function cancels the subscription.
+func (l *LiveToolset) Subscribe(fn func(LiveChange)) (cancel func()) {
+	if fn == nil {
+		return func() {}
+	}
+	l.subMu.Lock()
+	id := l.nextID
+	l.nextID++
+	l.subs[id] = fn
+	l.subMu.Unlock()
+
+	return func() {
+		l.subMu.Lock()
+		delete(l.subs, id)
+		l.subMu.Unlock()
+	}
+}
+
+// Close stops watching the sources. The last published toolset remains
+// available. Close is idempotent.
+func (l *LiveToolset) Close() {
+	l.stopOnce.Do(func() {
+		for _, stop := range l.stops {
+			if stop != nil {
+				stop()
+			}
+		}
+	})
+}
+
+// notify delivers change to all subscribers in subscription order.
+func (l *LiveToolset) notify(change LiveChange) {
+	l.subMu.Lock()
+	ids := make([]uint64, 0, len(l.subs))
+	for id := range l.subs {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	fns := make([]func(LiveChange), len(ids))
+	for i, id := range ids {
+		fns[i] = l.subs[id]
+	}
+	l.subMu.Unlock()
+
+	for _, fn := range fns {
+		fn(change)
+	}
+}
+
+// watcher is the watch half of WatchableRegistry. ContextRegistry sources
+// that implement it are watched too.
+type watcher interface {
+	Watch(fn func()) (stop func())
+}
+
+// watchableSources returns the Builder's sources that can be watched.
+func watchableSources(b *Builder) []watcher {
+	var out []watcher
+	for _, src := range b.sources {
+		if w, ok := src.origin.(watcher); ok {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// diffLive computes the LiveChange between two toolsets.
+func diffLive(prev, next *Toolset) LiveChange {
+	change := LiveChange{Toolset: next}
+	before := prev.Tools()
+	after := next.Tools()
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case j == len(after) || i < len(before) && before[i].ID() < after[j].ID():
+			change.Removed = append(change.Removed, before[i].ID())
+			i++
+		case i == len(before) || after[j].ID() < before[i].ID():
+			change.Added = append(change.Added, after[j].ID())
+			j++
+		default:
+			if !sameTool(before[i], after[j]) {
+				change.Changed = append(change.Changed, after[j].ID())
+			}
+			i++
+			j++
+		}
+	}
+	return change
+}
+
+// sameTool reports whether two tools have identical definitions. It
+// compares the content encoding behind ETags, so every field counts and a
+// NaN in a schema equals itself.
+func sameTool(a, b *tooladapter.CanonicalTool) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	var bufA, bufB bytes.Buffer
+	writeToolContent(&bufA, a)
+	writeToolContent(&bufB, b)
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
@@ -0,0 +1,241 @@
+package toolset
+
+import (
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// watchRegistry is a WatchableRegistry that notifies synchronously on Set.
+type watchRegistry struct {
+	mu       sync.Mutex
+	tools    []*tooladapter.CanonicalTool
+	watchers map[int]func()
+	next     int
+}
+
+func (r *watchRegistry) Tools() []*tooladapter.CanonicalTool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*tooladapter.CanonicalTool(nil), r.tools...)
+}
+
+func (r *watchRegistry) Watch(fn func()) func() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.watchers == nil {
+		r.watchers = make(map[int]func())
+	}
+	id := r.next
+	r.next++
+	r.watchers[id] = fn
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.watchers, id)
+	}
+}
+
+func (r *watchRegistry) Set(tools ...*tooladapter.CanonicalTool) {
+	r.mu.Lock()
+	r.tools = tools
+	var fns []func()
+	for _, fn := range r.watchers {
+		fns = append(fns, fn)
+	}
+	r.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// windowRegistry runs during once, after the first Tools call has read the
+// tools, so a change lands while the initial build is in progress.
+type windowRegistry struct {
+	watchRegistry
+	during func()
+}
+
+func (r *windowRegistry) Tools() []*tooladapter.CanonicalTool {
+	tools := r.watchRegistry.Tools()
+	if during := r.during; during != nil {
+		r.during = nil
+		during()
+	}
+	return tools
+}
+
+func TestLiveToolset(t *testing.T) {
+	t.Run("rebuilds on change and reports diff", func(t *testing.T) {
+		reg := &watchRegistry{}
+		reg.Set(makeTool("mcp", "a", []string{"safe"}), makeTool("mcp", "b", []string{"safe"}))
+
+		live, err := NewLiveToolset(NewBuilder("live").FromRegistry(reg).WithTags([]string{"safe"}))
+		if err != nil {
+			t.Fatalf("NewLiveToolset() error = %v", err)
+		}
+		defer live.Close()
+		if live.Toolset().Count() != 2 {
+			t.Fatalf("initial Count() = %d, want 2", live.Toolset().Count())
+		}
+
+		var changes []LiveChange
+		cancel := live.Subscribe(func(c LiveChange) { changes = append(changes, c) })
+		defer cancel()
+
+		changedB := makeTool("mcp", "b", []string{"safe"})
+		changedB.Description = "updated"
+		reg.Set(
+			changedB,
+			makeTool("mcp", "c", []string{"safe"}),
+			makeTool("mcp", "d", []string{"danger"}),
+		)
+
+		if len(changes) != 1 {
+			t.Fatalf("got %d changes, want 1", len(changes))
+		}
+		c := changes[0]
+		if !reflect.DeepEqual(c.Added, []string{"mcp:c"}) ||
+			!reflect.DeepEqual(c.Removed, []string{"mcp:a"}) ||
+			!reflect.DeepEqual(c.Changed, []string{"mcp:b"}) {
+			t.Errorf("change = %+v", c)
+		}
+		if c.Toolset != live.Toolset() {
+			t.Error("change should carry the published toolset")
+		}
+		if !reflect.DeepEqual(live.Toolset().IDs(), []string{"mcp:b", "mcp:c"}) {
+			t.Errorf("IDs() = %v", live.Toolset().IDs())
+		}
+	})
+
+	t.Run("equal rebuild is not reported", func(t *testing.T) {
+		reg := &watchRegistry{}
+		reg.Set(makeTool("mcp", "a", nil))
+		live, err := NewLiveToolset(NewBuilder("live").FromRegistry(reg))
+		if err != nil {
+			t.Fatalf("NewLiveToolset() error = %v", err)
+		}
+		defer live.Close()
+
+		calls := 0
+		live.Subscribe(func(LiveChange) { calls++ })
+		reg.Set(makeTool("mcp", "a", nil)) // new pointer, same content
+		if calls != 0 {
+			t.Errorf("subscriber called %d times, want 0", calls)
+		}
+	})
+
+	t.Run("NaN in a schema is not a change", func(t *testing.T) {
+		nanTool := func() *tooladapter.CanonicalTool {
+			tool := makeTool("mcp", "a", nil)
+			tool.InputSchema = &tooladapter.JSONSchema{Type: "number", Default: math.NaN()}
+			return tool
+		}
+		reg := &watchRegistry{}
+		reg.Set(nanTool())
+		live, err := NewLiveToolset(NewBuilder("live").FromRegistry(reg))
+		if err != nil {
+			t.Fatalf("NewLiveToolset() error = %v", err)
+		}
+		defer live.Close()
+
+		var changes []LiveChange
+		live.Subscribe(func(c LiveChange) { changes = append(changes, c) })
+		reg.Set(nanTool())
+		if len(changes) != 0 {
+			t.Errorf("changes = %+v, want none", changes)
+		}
+	})
+
+	t.Run("cancel and close stop delivery", func(t *testing.T) {
+		reg := &watchRegistry{}
+		live, err := NewLiveToolset(NewBuilder("live").FromRegistry(reg))
+		if err != nil {
+			t.Fatalf("NewLiveToolset() error = %v", err)
+		}
+
+		calls := 0
+		cancel := live.Subscribe(func(LiveChange) { calls++ })
+		reg.Set(makeTool("mcp", "a", nil))
+		cancel()
+		reg.Set(makeTool("mcp", "b", nil))
+		if calls != 1 {
+			t.Errorf("subscriber called %d times, want 1", calls)
+		}
+
+		live.Close()
+		live.Close()
+		reg.Set(makeTool("mcp", "c", nil))
+		if got := live.Toolset().IDs(); !reflect.DeepEqual(got, []string{"mcp:b"}) {
+			t.Errorf("closed LiveToolset should not rebuild, IDs() = %v", got)
+		}
+	})
+
+	t.Run("keeps changes made during the initial build", func(t *testing.T) {
+		reg := &windowRegistry{}
+		reg.Set(makeTool("mcp", "a", nil))
+		reg.during = func() { reg.Set(makeTool("mcp", "a", nil), makeTool("mcp", "b", nil)) }
+
+		live, err := NewLiveToolset(NewBuilder("live").FromRegistry(reg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer live.Close()
+		if got := live.Toolset().IDs(); !reflect.DeepEqual(got, []string{"mcp:a", "mcp:b"}) {
+			t.Errorf("IDs() = %v, want the change made during the build", got)
+		}
+	})
+
+	t.Run("requires watchable source", func(t *testing.T) {
+		_, err := NewLiveToolset(NewBuilder("live").FromRegistry(&mockRegistry{}))
+		if err == nil {
+			t.Error("expected error for non-watchable registry")
+		}
+	})
+
+	t.Run("watches sources combined by a spec", func(t *testing.T) {
+		reg := &watchRegistry{}
+		b, err := (&Spec{Name: "x", Sources: []string{"static", "live"}}).Builder(map[string]Registry{
+			"static": &mockRegistry{tools: []*tooladapter.CanonicalTool{makeTool("s", "a", nil)}},
+			"live":   reg,
+		})
+		if err != nil {
+			t.Fatalf("Builder() error = %v", err)
+		}
+		live, err := NewLiveToolset(b)
+		if err != nil {
+			t.Fatalf("NewLiveToolset() error = %v", err)
+		}
+		defer live.Close()
+		reg.Set(makeTool("l", "b", nil))
+		if live.Toolset().Count() != 2 {
+			t.Errorf("Count() = %d, want 2", live.Toolset().Count())
+		}
+	})
+
+	t.Run("concurrent refresh", func(t *testing.T) {
+		reg := &watchRegistry{}
+		live, err := NewLiveToolset(NewBuilder("live").FromRegistry(reg))
+		if err != nil {
+			t.Fatalf("NewLiveToolset() error = %v", err)
+		}
+		defer live.Close()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				reg.Set(makeTool("mcp", "a", nil))
+				_ = live.Toolset().Count()
+			}()
+		}
+		wg.Wait()
+		if live.Toolset().Count() != 1 {
+			t.Errorf("Count() = %d, want 1", live.Toolset().Count())
+		}
+	})
+}
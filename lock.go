@@ -0,0 +1,213 @@
+package toolset
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// LockVersion is the version of the lock format written by NewLock.
+const LockVersion = 1
+
+// Lock pins the exact tool surface of a toolset: every tool ID with its
+// content fingerprint (see Fingerprint), and the toolset fingerprint as
+// Root. It marshals to JSON via its struct tags; use ParseLock to read it
+// back with validation.
+type Lock struct {
+	// Version is the lock format version.
+	Version int `json:"version"`
+
+	// Toolset is the name of the locked toolset.
+	Toolset string `json:"toolset"`
+
+	// Root is the toolset fingerprint (see Toolset.Fingerprint).
+	Root string `json:"root"`
+
+	// Tools lists the locked tools sorted by ID.
+	Tools []LockEntry `json:"tools"`
+}
+
+// LockEntry pins a single tool.
+type LockEntry struct {
+	ID          string `json:"id"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// LockMismatchError is returned by Verify when a toolset differs from its
+// lock. Diff compares the lock (From) with the toolset (To); modified tools
+// carry a single "fingerprint" change with the locked and actual values.
+type LockMismatchError struct {
+	Toolset string
+	Diff    *ToolsetDiff
+}
+
+func (e *LockMismatchError) Error() string {
+	var parts []string
+	if len(e.Diff.Added) > 0 {
+		parts = append(parts, "added "+strings.Join(e.Diff.Added, ", "))
+	}
+	if len(e.Diff.Removed) > 0 {
+		parts = append(parts, "removed "+strings.Join(e.Diff.Removed, ", "))
+	}
+	if len(e.Diff.Modified) > 0 {
+		ids := make([]string, len(e.Diff.Modified))
+		for i, m := range e.Diff.Modified {
+			ids[i] = m.ID
+		}
+		parts = append(parts, "changed "+strings.Join(ids, ", "))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "fingerprint differs")
+	}
+	return fmt.Sprintf("toolset %q does not match its lock: %s", e.Toolset, strings.Join(parts, "; "))
+}
+
+// NewLock returns a lock pinning the current tools of ts.
+func NewLock(ts *Toolset) *Lock {
+	snap := ts.Snapshot()
+	return &Lock{
+		Version: LockVersion,
+		Toolset: snap.name,
+		Root:    snap.Fingerprint(),
+		Tools:   lockEntries(snap),
+	}
+}
+
+// UpdateLock intentionally regenerates the lock for ts and reports how it
+// differs from old, for review. A nil old lock reports every tool as added.
+func UpdateLock(ts *Toolset, old *Lock) (*Lock, *ToolsetDiff) {
+	lock := NewLock(ts)
+	var locked []LockEntry
+	if old != nil {
+		locked = old.Tools
+	}
+	return lock, diffLock(locked, lock.Tools, lock.Toolset)
+}
+
+// Verify checks that ts has exactly the tools pinned by lock. It fails
+// closed: a nil or invalid lock, a lock for another toolset name, and any
+// added, removed or changed tool are errors. Differences in tools are
+// reported as a *LockMismatchError.
+func Verify(ts *Toolset, lock *Lock) error {
+	if ts == nil {
+		return errors.New("toolset is nil")
+	}
+	if lock == nil {
+		return errors.New("lock is nil")
+	}
+	if err := lock.Validate(); err != nil {
+		return fmt.Errorf("invalid lock: %w", err)
+	}
+	snap := ts.Snapshot()
+	if lock.Toolset != snap.name {
+		return fmt.Errorf("lock is for toolset %q, not %q", lock.Toolset, snap.name)
+	}
+	if snap.Fingerprint() == lock.Root {
+		return nil
+	}
+	return &LockMismatchError{Toolset: snap.name, Diff: diffLock(lock.Tools, lockEntries(snap), snap.name)}
+}
+
+// ParseLock decodes a JSON lock and validates it. Unknown fields and
+// invalid values are reported as ValidationErrors.
+func ParseLock(data []byte) (*Lock, error) {
+	var lock Lock
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&lock); err != nil {
+		return nil, jsonFieldError(data, err)
+	}
+	if err := lock.Validate(); err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// Validate checks the lock's version, that tools are sorted by unique ID
+// with well-formed fingerprints, and that Root matches the tool
+// fingerprints. It returns ValidationErrors listing every problem found.
+func (l *Lock) Validate() error {
+	var errs ValidationErrors
+	switch {
+	case l.Version == 0:
+		errs = append(errs, &FieldError{Path: "version", Msg: "is required"})
+	case l.Version != LockVersion:
+		errs = append(errs, &FieldError{Path: "version", Msg: fmt.Sprintf("unsupported version %d (supported: %d)", l.Version, LockVersion)})
+	}
+	if _, ok := parseDigest(l.Root); !ok {
+		errs = append(errs, &FieldError{Path: "root", Msg: "must be \"sha256:\" followed by 64 hex digits"})
+	}
+	leaves := make([][32]byte, 0, len(l.Tools))
+	for i, e := range l.Tools {
+		path := indexPath("tools", i)
+		switch {
+		case e.ID == "":
+			errs = append(errs, &FieldError{Path: fieldPath(path, "id"), Msg: "is required"})
+		case i > 0 && e.ID <= l.Tools[i-1].ID:
+			errs = append(errs, &FieldError{Path: fieldPath(path, "id"), Msg: fmt.Sprintf("%q is duplicate or out of order", e.ID)})
+		}
+		digest, ok := parseDigest(e.Fingerprint)
+		if !ok {
+			errs = append(errs, &FieldError{Path: fieldPath(path, "fingerprint"), Msg: "must be \"sha256:\" followed by 64 hex digits"})
+			continue
+		}
+		leaves = append(leaves, digest)
+	}
+	if len(errs) == 0 {
+		if root := merkleRoot(leaves); l.Root != "sha256:"+hex.EncodeToString(root[:]) {
+			errs = append(errs, &FieldError{Path: "root", Msg: "does not match the tool fingerprints"})
+		}
+	}
+	return errs.err()
+}
+
+// parseDigest parses a "sha256:<hex>" fingerprint.
+func parseDigest(s string) ([32]byte, bool) {
+	var digest [32]byte
+	hexDigits, ok := strings.CutPrefix(s, "sha256:")
+	if !ok || len(hexDigits) != 64 {
+		return digest, false
+	}
+	if _, err := hex.Decode(digest[:], []byte(hexDigits)); err != nil {
+		return digest, false
+	}
+	return digest, true
+}
+
+// lockEntries pins the tools of a snapshot.
+func lockEntries(s *Snapshot) []LockEntry {
+	entries := make([]LockEntry, len(s.tools))
+	for i, t := range s.tools {
+		entries[i] = LockEntry{ID: s.ids[i], Fingerprint: Fingerprint(t)}
+	}
+	return entries
+}
+
+// diffLock compares locked entries with actual ones; both are sorted by ID.
+func diffLock(locked, actual []LockEntry, name string) *ToolsetDiff {
+	d := &ToolsetDiff{From: "lock", To: name, Added: []string{}, Removed: []string{}, Modified: []ToolDiff{}}
+	i, j := 0, 0
+	for i < len(locked) || j < len(actual) {
+		switch {
+		case j == len(actual) || i < len(locked) && locked[i].ID < actual[j].ID:
+			d.Removed = append(d.Removed, locked[i].ID)
+			i++
+		case i == len(locked) || actual[j].ID < locked[i].ID:
+			d.Added = append(d.Added, actual[j].ID)
+			j++
+		default:
+			if locked[i].Fingerprint != actual[j].Fingerprint {
+				d.Modified = append(d.Modified, ToolDiff{
+					ID:      actual[j].ID,
+					Changes: []FieldChange{{Field: "fingerprint", Old: locked[i].Fingerprint, New: actual[j].Fingerprint}},
+				})
+			}
+			i++
+			j++
+		}
+	}
+	return d
+}
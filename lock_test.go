@@ -0,0 +1,141 @@
+package toolset
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func lockedToolset() *Toolset {
+	ts := New("prod")
+	ts.Add(makeTool("github", "search", []string{"read"}))
+	ts.Add(makeTool("github", "create_issue", []string{"write"}))
+	ts.Add(makeTool("slack", "send", nil))
+	return ts
+}
+
+func TestLock(t *testing.T) {
+	t.Run("verifies the locked toolset", func(t *testing.T) {
+		ts := lockedToolset()
+		lock := NewLock(ts)
+		if lock.Version != LockVersion || lock.Toolset != "prod" || lock.Root != ts.Fingerprint() || len(lock.Tools) != 3 {
+			t.Fatalf("NewLock() = %+v", lock)
+		}
+		if lock.Tools[0].ID != "github:create_issue" || lock.Tools[0].Fingerprint != Fingerprint(makeTool("github", "create_issue", []string{"write"})) {
+			t.Errorf("first entry = %+v", lock.Tools[0])
+		}
+		if err := Verify(ts, lock); err != nil {
+			t.Errorf("Verify() = %v", err)
+		}
+		if err := Verify(lockedToolset(), lock); err != nil {
+			t.Errorf("Verify() of an equal toolset = %v", err)
+		}
+	})
+
+	t.Run("reports a precise diff", func(t *testing.T) {
+		ts := lockedToolset()
+		lock := NewLock(ts)
+		ts.Remove("slack:send")
+		ts.Add(makeTool("github", "delete_repo", nil))
+		ts.Add(makeTool("github", "search", []string{"read", "beta"}))
+
+		err := Verify(ts, lock)
+		var mismatch *LockMismatchError
+		if !errors.As(err, &mismatch) {
+			t.Fatalf("Verify() = %v, want LockMismatchError", err)
+		}
+		d := mismatch.Diff
+		if !reflect.DeepEqual(d.Added, []string{"github:delete_repo"}) || !reflect.DeepEqual(d.Removed, []string{"slack:send"}) {
+			t.Errorf("added %v, removed %v", d.Added, d.Removed)
+		}
+		if len(d.Modified) != 1 || d.Modified[0].ID != "github:search" || d.Modified[0].Changes[0].Field != "fingerprint" {
+			t.Fatalf("modified = %+v", d.Modified)
+		}
+		if c := d.Modified[0].Changes[0]; c.Old == c.New {
+			t.Errorf("change = %+v", c)
+		}
+		want := `toolset "prod" does not match its lock: added github:delete_repo; removed slack:send; changed github:search`
+		if err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err, want)
+		}
+	})
+
+	t.Run("fails closed", func(t *testing.T) {
+		ts := lockedToolset()
+		lock := NewLock(ts)
+
+		renamed := *lock
+		renamed.Toolset = "staging"
+		tampered := *lock
+		tampered.Tools = append([]LockEntry(nil), lock.Tools[1:]...)
+		for name, l := range map[string]*Lock{"nil": nil, "other toolset": &renamed, "inconsistent root": &tampered, "empty": {}} {
+			if err := Verify(ts, l); err == nil {
+				t.Errorf("%s: Verify() = nil, want error", name)
+			}
+		}
+		if err := Verify(nil, lock); err == nil {
+			t.Error("Verify(nil) = nil, want error")
+		}
+	})
+
+	t.Run("UpdateLock regenerates and reports changes", func(t *testing.T) {
+		ts := lockedToolset()
+		old := NewLock(ts)
+		ts.Remove("slack:send")
+
+		lock, diff := UpdateLock(ts, old)
+		if err := Verify(ts, lock); err != nil {
+			t.Errorf("Verify() after UpdateLock = %v", err)
+		}
+		if !reflect.DeepEqual(diff.Removed, []string{"slack:send"}) || len(diff.Added)+len(diff.Modified) != 0 {
+			t.Errorf("diff = %+v", diff)
+		}
+		if _, diff := UpdateLock(ts, nil); len(diff.Added) != 2 {
+			t.Errorf("diff against nil lock = %+v", diff)
+		}
+	})
+}
+
+func TestParseLock(t *testing.T) {
+	t.Run("round-trips", func(t *testing.T) {
+		ts := lockedToolset()
+		data, err := json.MarshalIndent(NewLock(ts), "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		lock, err := ParseLock(data)
+		if err != nil {
+			t.Fatalf("ParseLock() error = %v", err)
+		}
+		if err := Verify(ts, lock); err != nil {
+			t.Errorf("Verify() = %v", err)
+		}
+	})
+
+	root := NewLock(New("empty")).Root
+	fp := Fingerprint(makeTool("", "a", nil))
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{"unknown field", `{"version":1,"toolset":"x","root":"` + root + `","tools":[],"signed":true}`, `unknown field "signed"`},
+		{"version", `{"toolset":"x","root":"` + root + `","tools":[]}`, "version: is required"},
+		{"newer version", `{"version":9,"toolset":"x","root":"` + root + `","tools":[]}`, "version: unsupported version 9"},
+		{"bad root", `{"version":1,"root":"md5:00","tools":[]}`, "root: must be"},
+		{"bad fingerprint", `{"version":1,"root":"` + root + `","tools":[{"id":"a","fingerprint":"sha256:xyz"}]}`, "tools[0].fingerprint: must be"},
+		{"order", `{"version":1,"root":"` + root + `","tools":[{"id":"b","fingerprint":"` + fp + `"},{"id":"a","fingerprint":"` + fp + `"}]}`, `tools[1].id: "a" is duplicate or out of order`},
+		{"root mismatch", `{"version":1,"root":"` + root + `","tools":[{"id":"a","fingerprint":"` + fp + `"}]}`, "root: does not match the tool fingerprints"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLock([]byte(tt.doc))
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseLock() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package toolset
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// LoweringAction identifies a schema lowering transformation.
+type LoweringAction string
+
+const (
+	// LoweringInlined replaced a $ref with the referenced schema.
+	LoweringInlined LoweringAction = "inlined"
+	// LoweringRecursive replaced a recursive $ref with an unconstrained schema.
+	LoweringRecursive LoweringAction = "recursion-cut"
+	// LoweringDropped removed $defs after their references were inlined.
+	LoweringDropped LoweringAction = "dropped"
+	// LoweringFlattened merged allOf branches into the parent schema.
+	LoweringFlattened LoweringAction = "flattened"
+	// LoweringEnum replaced anyOf/oneOf of constants with an enum.
+	LoweringEnum LoweringAction = "enum"
+	// LoweringDescribed moved a validation keyword into the description.
+	LoweringDescribed LoweringAction = "described"
+	// LoweringLimited replaced references with unconstrained schemas after
+	// the inlining limit was reached.
+	LoweringLimited LoweringAction = "limit-exceeded"
+)
+
+// maxInlinedRefs caps the references inlined per schema. Each inlining
+// copies its target, so without a cap a schema whose definitions each
+// reference the next twice would grow exponentially.
+const maxInlinedRefs = 1000
+
+// WithSchemaLowering enables rewriting schemas before conversion so that
+// unsupported features degrade gracefully instead of being passed through.
+// Lowering works on a copy; the Toolset's tools are never modified.
+//
+// For each feature the adapter does not support:
+//   - $ref / $defs: local references ("#" and "#/$defs/...") are inlined and
+//     the root $defs dropped; recursive references become unconstrained schemas,
+//     as do references beyond the first 1000 in a schema.
+//   - allOf: branches are merged into the parent.
+//   - anyOf / oneOf: branches that are all constants become an enum.
+//   - pattern, format, minimum, maximum, minLength, maxLength: moved into the
+//     description text.
+//
+// Each transformation is reported as a SchemaWarning with Action set.
+// Features that cannot be lowered are still reported with an empty Action.
+// It returns e for chaining.
+func (e *Exposure) WithSchemaLowering(lower bool) *Exposure {
+	e.lowerSchemas = lower
+	return e
+}
+
+// prepare returns the tool to convert and the lowering warnings.
+func (e *Exposure) prepare(t *tooladapter.CanonicalTool, from string) (*tooladapter.CanonicalTool, []SchemaWarning) {
+	if !e.lowerSchemas || t == nil {
+		return t, nil
+	}
+	l := &schemaLowering{adapter: e.adapter, toolID: t.ID(), from: from}
+	lowered := *t
+	l.which = "input"
+	lowered.InputSchema = l.lowerRoot(t.InputSchema.DeepCopy())
+	l.which = "output"
+	lowered.OutputSchema = l.lowerRoot(t.OutputSchema.DeepCopy())
+	return &lowered, l.warnings
+}
+
+// schemaLowering rewrites one tool's schemas in place.
+type schemaLowering struct {
+	adapter  tooladapter.Adapter
+	toolID   string
+	from     string
+	which    string
+	defs     map[string]*tooladapter.JSONSchema
+	inlined  int // references inlined in the current root schema
+	warnings []SchemaWarning
+}
+
+func (l *schemaLowering) supports(f tooladapter.SchemaFeature) bool {
+	return l.adapter.SupportsFeature(f)
+}
+
+func (l *schemaLowering) note(f tooladapter.SchemaFeature, pointer string, action LoweringAction, detail string) {
+	l.warnings = append(l.warnings, SchemaWarning{
+		FeatureLossWarning: tooladapter.FeatureLossWarning{
+			Feature:     f,
+			FromAdapter: l.from,
+			ToAdapter:   l.adapter.Name(),
+		},
+		ToolID:  l.toolID,
+		Schema:  l.which,
+		Pointer: pointer,
+		Action:  action,
+		Detail:  detail,
+	})
+}
+
+// inlineRefs reports whether local references must be inlined.
+func (l *schemaLowering) inlineRefs() bool {
+	return !l.supports(tooladapter.FeatureRef) || !l.supports(tooladapter.FeatureDefs)
+}
+
+// lowerRoot lowers a root schema (already a private copy).
+func (l *schemaLowering) lowerRoot(root *tooladapter.JSONSchema) *tooladapter.JSONSchema {
+	if root == nil {
+		return nil
+	}
+	l.defs = root.Defs
+	l.inlined = 0
+	if l.inlineRefs() && len(root.Defs) > 0 {
+		root.Defs = nil
+		l.note(tooladapter.FeatureDefs, "/$defs", LoweringDropped, "")
+	}
+	l.lower(root, "", []string{"#"})
+	return root
+}
+
+// lookup resolves a "#/$defs/..." reference against the root $defs.
+// A reference to the root itself ("#") is always recursive and is handled
+// by the caller.
+func (l *schemaLowering) lookup(ref string) *tooladapter.JSONSchema {
+	name, ok := strings.CutPrefix(ref, "#/$defs/")
+	if !ok || strings.Contains(name, "/") {
+		return nil
+	}
+	name = strings.ReplaceAll(strings.ReplaceAll(name, "~1", "/"), "~0", "~")
+	return l.defs[name].DeepCopy()
+}
+
+// lower rewrites s and its subschemas. refs is the chain of references
+// being inlined, used to detect recursion.
+func (l *schemaLowering) lower(s *tooladapter.JSONSchema, pointer string, refs []string) {
+	if s == nil {
+		return
+	}
+
+	for s.Ref != "" && l.inlineRefs() {
+		ref := s.Ref
+		if containsString(refs, ref) {
+			*s = tooladapter.JSONSchema{Description: s.Description}
+			l.note(tooladapter.FeatureRef, pointer+"/$ref", LoweringRecursive, ref)
+			return
+		}
+		target := l.lookup(ref)
+		if target == nil {
+			break // not local; left for the adapter
+		}
+		if l.inlined >= maxInlinedRefs {
+			if l.inlined == maxInlinedRefs { // report the limit once
+				l.note(tooladapter.FeatureRef, pointer+"/$ref", LoweringLimited, "inlined "+strconv.Itoa(maxInlinedRefs)+" references; remaining references are unconstrained")
+				l.inlined++
+			}
+			*s = tooladapter.JSONSchema{Description: s.Description}
+			return
+		}
+		l.inlined++
+		refs = append(refs[:len(refs):len(refs)], ref)
+		s.Ref = ""
+		mergeSchema(s, target) // sibling keywords take precedence
+		l.note(tooladapter.FeatureRef, pointer+"/$ref", LoweringInlined, ref)
+	}
+
+	if len(s.AllOf) > 0 && !l.supports(tooladapter.FeatureAllOf) {
+		branches := s.AllOf
+		s.AllOf = nil
+		var conflicts []string
+		for i, b := range branches {
+			l.lower(b, pointer+"/allOf/"+strconv.Itoa(i), refs)
+			if b == nil {
+				continue
+			}
+			// Keep every branch's description; it may carry lowered constraints.
+			descr := b.Description
+			b.Description = ""
+			conflicts = append(conflicts, mergeSchema(s, b)...)
+			s.Description = joinDescription(s.Description, descr)
+		}
+		var detail string
+		if len(conflicts) > 0 {
+			detail = "kept parent value for " + strings.Join(sortedSet(conflicts), ", ")
+		}
+		l.note(tooladapter.FeatureAllOf, pointer+"/allOf", LoweringFlattened, detail)
+	}
+
+	s.AnyOf = l.constsToEnum(s, s.AnyOf, tooladapter.FeatureAnyOf, pointer+"/anyOf")
+	s.OneOf = l.constsToEnum(s, s.OneOf, tooladapter.FeatureOneOf, pointer+"/oneOf")
+	l.describeConstraints(s, pointer)
+
+	for _, name := range sortedKeys(s.Properties) {
+		l.lower(s.Properties[name], pointer+"/properties/"+escapePointer(name), refs)
+	}
+	l.lower(s.Items, pointer+"/items", refs)
+	for _, name := range sortedKeys(s.Defs) {
+		l.lower(s.Defs[name], pointer+"/$defs/"+escapePointer(name), refs)
+	}
+	for i, b := range s.AnyOf {
+		l.lower(b, pointer+"/anyOf/"+strconv.Itoa(i), refs)
+	}
+	for i, b := range s.OneOf {
+		l.lower(b, pointer+"/oneOf/"+strconv.Itoa(i), refs)
+	}
+	l.lower(s.Not, pointer+"/not", refs)
+}
+
+// constsToEnum replaces branches that are all plain constants with an enum
+// on s when the combinator is unsupported. It returns the remaining branches.
+func (l *schemaLowering) constsToEnum(s *tooladapter.JSONSchema, branches []*tooladapter.JSONSchema, f tooladapter.SchemaFeature, pointer string) []*tooladapter.JSONSchema {
+	if len(branches) == 0 || l.supports(f) || len(s.Enum) > 0 {
+		return branches
+	}
+	values := make([]any, 0, len(branches))
+	typ := branches[0].Type
+	for _, b := range branches {
+		if !isConstOnly(b) {
+			return branches
+		}
+		if b.Type != typ {
+			typ = ""
+		}
+		values = append(values, b.Const)
+	}
+	s.Enum = values
+	if s.Type == "" {
+		s.Type = typ
+	}
+	l.note(f, pointer, LoweringEnum, "")
+	return nil
+}
+
+// isConstOnly reports whether b is a constant with at most a type and
+// description.
+func isConstOnly(b *tooladapter.JSONSchema) bool {
+	if b == nil || b.Const == nil {
+		return false
+	}
+	rest := tooladapter.JSONSchema{Const: b.Const, Type: b.Type, Description: b.Description}
+	return reflect.DeepEqual(*b, rest)
+}
+
+// describeConstraints moves unsupported validation keywords into the
+// description, e.g. "Query text (pattern: ^q; minLength: 1)".
+func (l *schemaLowering) describeConstraints(s *tooladapter.JSONSchema, pointer string) {
+	var parts []string
+	move := func(f tooladapter.SchemaFeature, present bool, value string, clear func()) {
+		if !present || l.supports(f) {
+			return
+		}
+		keyword := featureKeywords[f]
+		parts = append(parts, keyword+": "+value)
+		clear()
+		l.note(f, pointer+"/"+keyword, LoweringDescribed, "")
+	}
+	move(tooladapter.FeaturePattern, s.Pattern != "", s.Pattern, func() { s.Pattern = "" })
+	move(tooladapter.FeatureFormat, s.Format != "", s.Format, func() { s.Format = "" })
+	move(tooladapter.FeatureMinimum, s.Minimum != nil, formatFloat(s.Minimum), func() { s.Minimum = nil })
+	move(tooladapter.FeatureMaximum, s.Maximum != nil, formatFloat(s.Maximum), func() { s.Maximum = nil })
+	move(tooladapter.FeatureMinLength, s.MinLength != nil, formatInt(s.MinLength), func() { s.MinLength = nil })
+	move(tooladapter.FeatureMaxLength, s.MaxLength != nil, formatInt(s.MaxLength), func() { s.MaxLength = nil })
+	if len(parts) == 0 {
+		return
+	}
+	s.Description = joinDescription(s.Description, "("+strings.Join(parts, "; ")+")")
+}
+
+// joinDescription appends text to a description, skipping empty or
+// repeated text.
+func joinDescription(descr, text string) string {
+	switch {
+	case text == "" || text == descr:
+		return descr
+	case descr == "":
+		return text
+	}
+	return descr + " " + text
+}
+
+// mergeSchema copies keywords from src into dst where dst has none.
+// Properties are merged by name and required lists unioned. It returns the
+// keywords (or property paths) where both were set and differed; dst wins.
+func mergeSchema(dst, src *tooladapter.JSONSchema) []string {
+	if src == nil {
+		return nil
+	}
+	var conflicts []string
+	str := func(keyword string, d *string, s string) {
+		switch {
+		case s == "":
+		case *d == "":
+			*d = s
+		case *d != s && keyword != "description":
+			conflicts = append(conflicts, keyword)
+		}
+	}
+	str("type", &dst.Type, src.Type)
+	str("description", &dst.Description, src.Description)
+	str("pattern", &dst.Pattern, src.Pattern)
+	str("format", &dst.Format, src.Format)
+	str("$ref", &dst.Ref, src.Ref)
+
+	val := func(keyword string, d *any, s any) {
+		switch {
+		case s == nil:
+		case *d == nil:
+			*d = s
+		case !reflect.DeepEqual(*d, s):
+			conflicts = append(conflicts, keyword)
+		}
+	}
+	val("const", &dst.Const, src.Const)
+	val("default", &dst.Default, src.Default)
+
+	mergePtr(&dst.Minimum, src.Minimum, "minimum", &conflicts)
+	mergePtr(&dst.Maximum, src.Maximum, "maximum", &conflicts)
+	mergePtr(&dst.MinLength, src.MinLength, "minLength", &conflicts)
+	mergePtr(&dst.MaxLength, src.MaxLength, "maxLength", &conflicts)
+	mergePtr(&dst.AdditionalProperties, src.AdditionalProperties, "additionalProperties", &conflicts)
+	mergePtr(&dst.Items, src.Items, "items", &conflicts)
+	mergePtr(&dst.Not, src.Not, "not", &conflicts)
+
+	if len(src.Enum) > 0 {
+		if len(dst.Enum) == 0 {
+			dst.Enum = src.Enum
+		} else if !reflect.DeepEqual(dst.Enum, src.Enum) {
+			conflicts = append(conflicts, "enum")
+		}
+	}
+	for _, name := range sortedKeys(src.Properties) {
+		prop := src.Properties[name]
+		if dst.Properties == nil {
+			dst.Properties = make(map[string]*tooladapter.JSONSchema)
+		}
+		existing, ok := dst.Properties[name]
+		switch {
+		case !ok:
+			dst.Properties[name] = prop
+		case !reflect.DeepEqual(existing, prop):
+			conflicts = append(conflicts, "properties/"+escapePointer(name))
+		}
+	}
+	if len(src.Required) > 0 {
+		dst.Required = sortedSet(append(dst.Required, src.Required...))
+	}
+	for name, def := range src.Defs {
+		if dst.Defs == nil {
+			dst.Defs = make(map[string]*tooladapter.JSONSchema)
+		}
+		if _, ok := dst.Defs[name]; !ok {
+			dst.Defs[name] = def
+		}
+	}
+	dst.AnyOf = append(dst.AnyOf, src.AnyOf...)
+	dst.OneOf = append(dst.OneOf, src.OneOf...)
+	dst.AllOf = append(dst.AllOf, src.AllOf...)
+	return conflicts
+}
+
+// mergePtr sets *dst to src when unset and records a conflict when both
+// are set and differ.
+func mergePtr[T any](dst **T, src *T, keyword string, conflicts *[]string) {
+	switch {
+	case src == nil:
+	case *dst == nil:
+		*dst = src
+	case !reflect.DeepEqual(*dst, src):
+		*conflicts = append(*conflicts, keyword)
+	}
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'g', -1, 64)
+}
+
+func formatInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
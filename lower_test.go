@@ -0,0 +1,241 @@
+package toolset
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// captureAdapter records the tools passed to FromCanonical.
+type captureAdapter struct {
+	*mockAdapter
+	got []*tooladapter.CanonicalTool
+}
+
+func (c *captureAdapter) FromCanonical(tool *tooladapter.CanonicalTool) (any, error) {
+	c.got = append(c.got, tool)
+	return c.mockAdapter.FromCanonical(tool)
+}
+
+// newCaptureAdapter returns an adapter supporting every feature except unsupported.
+func newCaptureAdapter(unsupported ...tooladapter.SchemaFeature) *captureAdapter {
+	supported := make(map[tooladapter.SchemaFeature]bool)
+	for _, f := range tooladapter.AllFeatures() {
+		supported[f] = true
+	}
+	for _, f := range unsupported {
+		supported[f] = false
+	}
+	return &captureAdapter{mockAdapter: &mockAdapter{name: "lite", supportedFeatures: supported}}
+}
+
+// lowerOne exports a single tool with lowering enabled and returns the
+// schema given to the adapter and the warnings.
+func lowerOne(t *testing.T, schema *tooladapter.JSONSchema, unsupported ...tooladapter.SchemaFeature) (*tooladapter.JSONSchema, []SchemaWarning) {
+	t.Helper()
+	ts := New("test")
+	ts.Add(&tooladapter.CanonicalTool{Namespace: "ns", Name: "tool", InputSchema: schema})
+	adapter := newCaptureAdapter(unsupported...)
+	_, warnings, errs := NewExposure(ts, adapter).WithSchemaLowering(true).ExportWithSchemaWarnings()
+	if len(errs) != 0 {
+		t.Fatalf("errs = %v", errs)
+	}
+	if len(adapter.got) != 1 {
+		t.Fatalf("FromCanonical called %d times, want 1", len(adapter.got))
+	}
+	return adapter.got[0].InputSchema, warnings
+}
+
+// warningSummary renders warnings as "pointer feature action detail".
+func warningSummary(warnings []SchemaWarning) []string {
+	out := make([]string, len(warnings))
+	for i, w := range warnings {
+		out[i] = w.Pointer + " " + w.Feature.String() + " " + string(w.Action) + " " + w.Detail
+	}
+	return out
+}
+
+func TestExposure_WithSchemaLowering(t *testing.T) {
+	t.Run("inlines refs and drops defs", func(t *testing.T) {
+		orig := &tooladapter.JSONSchema{
+			Type: "object",
+			Defs: map[string]*tooladapter.JSONSchema{
+				"User": {Type: "object", Properties: map[string]*tooladapter.JSONSchema{"name": {Type: "string"}}},
+			},
+			Properties: map[string]*tooladapter.JSONSchema{
+				"owner": {Ref: "#/$defs/User", Description: "Owner"},
+			},
+		}
+		want := orig.DeepCopy()
+
+		got, warnings := lowerOne(t, orig, tooladapter.FeatureRef)
+		if got.Defs != nil {
+			t.Errorf("Defs = %v, want nil", got.Defs)
+		}
+		owner := got.Properties["owner"]
+		if owner.Ref != "" || owner.Type != "object" || owner.Description != "Owner" || owner.Properties["name"] == nil {
+			t.Errorf("owner = %+v, want inlined User", owner)
+		}
+		wantWarnings := []string{
+			"/$defs $defs dropped ",
+			"/properties/owner/$ref $ref inlined #/$defs/User",
+		}
+		if !reflect.DeepEqual(warningSummary(warnings), wantWarnings) {
+			t.Errorf("warnings = %q, want %q", warningSummary(warnings), wantWarnings)
+		}
+		if !reflect.DeepEqual(orig, want) {
+			t.Error("original schema was modified")
+		}
+	})
+
+	t.Run("cuts recursive refs", func(t *testing.T) {
+		got, warnings := lowerOne(t, &tooladapter.JSONSchema{
+			Ref: "#/$defs/Node",
+			Defs: map[string]*tooladapter.JSONSchema{
+				"Node": {Type: "object", Properties: map[string]*tooladapter.JSONSchema{
+					"next": {Ref: "#/$defs/Node", Description: "Next node"},
+				}},
+			},
+		}, tooladapter.FeatureDefs)
+		next := got.Properties["next"]
+		if !reflect.DeepEqual(*next, tooladapter.JSONSchema{Description: "Next node"}) {
+			t.Errorf("next = %+v, want unconstrained schema", next)
+		}
+		wantWarnings := []string{
+			"/$defs $defs dropped ",
+			"/$ref $ref inlined #/$defs/Node",
+			"/properties/next/$ref $ref recursion-cut #/$defs/Node",
+		}
+		if !reflect.DeepEqual(warningSummary(warnings), wantWarnings) {
+			t.Errorf("warnings = %q, want %q", warningSummary(warnings), wantWarnings)
+		}
+	})
+
+	t.Run("caps exponential ref expansion", func(t *testing.T) {
+		defs := map[string]*tooladapter.JSONSchema{"d40": {Type: "string"}}
+		for i := 0; i < 40; i++ {
+			next := "#/$defs/d" + strconv.Itoa(i+1)
+			defs["d"+strconv.Itoa(i)] = &tooladapter.JSONSchema{Type: "object", Properties: map[string]*tooladapter.JSONSchema{
+				"a": {Ref: next},
+				"b": {Ref: next},
+			}}
+		}
+		_, warnings := lowerOne(t, &tooladapter.JSONSchema{Ref: "#/$defs/d0", Defs: defs}, tooladapter.FeatureDefs)
+
+		counts := make(map[LoweringAction]int)
+		for _, w := range warnings {
+			counts[w.Action]++
+		}
+		if counts[LoweringInlined] != maxInlinedRefs || counts[LoweringLimited] != 1 {
+			t.Errorf("inlined %d, limit warnings %d; want %d and 1", counts[LoweringInlined], counts[LoweringLimited], maxInlinedRefs)
+		}
+	})
+
+	t.Run("flattens allOf", func(t *testing.T) {
+		got, warnings := lowerOne(t, &tooladapter.JSONSchema{
+			Type: "object",
+			AllOf: []*tooladapter.JSONSchema{
+				{Properties: map[string]*tooladapter.JSONSchema{"a": {Type: "string"}}, Required: []string{"a"}},
+				{Type: "array", Properties: map[string]*tooladapter.JSONSchema{"b": {Type: "number"}}, Required: []string{"b"}},
+			},
+		}, tooladapter.FeatureAllOf)
+		if got.AllOf != nil || got.Type != "object" || len(got.Properties) != 2 {
+			t.Errorf("schema = %+v, want flattened object with a and b", got)
+		}
+		if !reflect.DeepEqual(got.Required, []string{"a", "b"}) {
+			t.Errorf("Required = %v, want [a b]", got.Required)
+		}
+		wantWarnings := []string{"/allOf allOf flattened kept parent value for type"}
+		if !reflect.DeepEqual(warningSummary(warnings), wantWarnings) {
+			t.Errorf("warnings = %q, want %q", warningSummary(warnings), wantWarnings)
+		}
+	})
+
+	t.Run("turns oneOf of constants into enum", func(t *testing.T) {
+		got, warnings := lowerOne(t, &tooladapter.JSONSchema{
+			OneOf: []*tooladapter.JSONSchema{
+				{Const: "asc", Type: "string"},
+				{Const: "desc", Type: "string"},
+			},
+		}, tooladapter.FeatureOneOf)
+		if got.OneOf != nil || got.Type != "string" || !reflect.DeepEqual(got.Enum, []any{"asc", "desc"}) {
+			t.Errorf("schema = %+v, want string enum", got)
+		}
+		if want := []string{"/oneOf oneOf enum "}; !reflect.DeepEqual(warningSummary(warnings), want) {
+			t.Errorf("warnings = %q, want %q", warningSummary(warnings), want)
+		}
+	})
+
+	t.Run("keeps non-constant oneOf with a loss warning", func(t *testing.T) {
+		got, warnings := lowerOne(t, &tooladapter.JSONSchema{
+			OneOf: []*tooladapter.JSONSchema{{Const: "asc"}, {Type: "integer"}},
+		}, tooladapter.FeatureOneOf)
+		if len(got.OneOf) != 2 {
+			t.Errorf("OneOf = %v, want unchanged", got.OneOf)
+		}
+		if want := []string{"/oneOf oneOf  "}; !reflect.DeepEqual(warningSummary(warnings), want) {
+			t.Errorf("warnings = %q, want %q", warningSummary(warnings), want)
+		}
+	})
+
+	t.Run("moves constraints into description", func(t *testing.T) {
+		minimum := 1.0
+		got, warnings := lowerOne(t, &tooladapter.JSONSchema{
+			Type: "object",
+			Properties: map[string]*tooladapter.JSONSchema{
+				"query": {Type: "string", Description: "Search text", Pattern: "^q", Format: "text"},
+				"limit": {Type: "integer", Minimum: &minimum},
+			},
+		}, tooladapter.FeaturePattern, tooladapter.FeatureFormat, tooladapter.FeatureMinimum)
+		query := got.Properties["query"]
+		if query.Pattern != "" || query.Format != "" || query.Description != "Search text (pattern: ^q; format: text)" {
+			t.Errorf("query = %+v", query)
+		}
+		if limit := got.Properties["limit"]; limit.Minimum != nil || limit.Description != "(minimum: 1)" {
+			t.Errorf("limit = %+v", limit)
+		}
+		wantWarnings := []string{
+			"/properties/limit/minimum minimum described ",
+			"/properties/query/format format described ",
+			"/properties/query/pattern pattern described ",
+		}
+		if !reflect.DeepEqual(warningSummary(warnings), wantWarnings) {
+			t.Errorf("warnings = %q, want %q", warningSummary(warnings), wantWarnings)
+		}
+	})
+
+	t.Run("supported features are untouched", func(t *testing.T) {
+		orig := &tooladapter.JSONSchema{Type: "string", Pattern: "^q"}
+		got, warnings := lowerOne(t, orig)
+		if !reflect.DeepEqual(got, orig) || len(warnings) != 0 {
+			t.Errorf("schema = %+v, warnings = %v; want unchanged", got, warnings)
+		}
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		schema := &tooladapter.JSONSchema{Type: "string", Pattern: "^q"}
+		ts := New("test")
+		ts.Add(&tooladapter.CanonicalTool{Namespace: "ns", Name: "tool", InputSchema: schema})
+		adapter := newCaptureAdapter(tooladapter.FeaturePattern)
+		if _, err := NewExposure(ts, adapter).Export(); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		if adapter.got[0].InputSchema != schema {
+			t.Error("schema should be passed through unchanged")
+		}
+	})
+
+	t.Run("Export uses lowered schema", func(t *testing.T) {
+		ts := New("test")
+		ts.Add(&tooladapter.CanonicalTool{Namespace: "ns", Name: "tool", InputSchema: &tooladapter.JSONSchema{Pattern: "^q"}})
+		adapter := newCaptureAdapter(tooladapter.FeaturePattern)
+		if _, err := NewExposure(ts, adapter).WithSchemaLowering(true).Export(); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		if got := adapter.got[0].InputSchema; got.Pattern != "" {
+			t.Errorf("Pattern = %q, want lowered", got.Pattern)
+		}
+	})
+}
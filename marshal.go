@@ -0,0 +1,349 @@
+package toolset
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// FormatVersion is the version of the JSON format written by
+// Toolset.MarshalJSON. UnmarshalJSON rejects documents with a newer version.
+const FormatVersion = 1
+
+// toolsetJSON is the serialized form of a Toolset:
+//
+//	{"format_version": 1, "name": "...", "tools": [{"name": "...", ...}]}
+type toolsetJSON struct {
+	FormatVersion int         `json:"format_version"`
+	Name          string      `json:"name"`
+	Tools         []*toolJSON `json:"tools"`
+}
+
+// toolJSON is the serialized form of a CanonicalTool. Timeout is a Go
+// duration string such as "1m30s".
+type toolJSON struct {
+	Namespace      string         `json:"namespace,omitempty"`
+	Name           string         `json:"name"`
+	Version        string         `json:"version,omitempty"`
+	Description    string         `json:"description,omitempty"`
+	Category       string         `json:"category,omitempty"`
+	Tags           []string       `json:"tags,omitempty"`
+	InputSchema    *schemaJSON    `json:"input_schema,omitempty"`
+	OutputSchema   *schemaJSON    `json:"output_schema,omitempty"`
+	Timeout        string         `json:"timeout,omitempty"`
+	SourceFormat   string         `json:"source_format,omitempty"`
+	SourceMeta     map[string]any `json:"source_meta,omitempty"`
+	RequiredScopes []string       `json:"required_scopes,omitempty"`
+}
+
+// schemaJSON is the serialized form of a JSONSchema, using JSON Schema
+// keywords.
+type schemaJSON struct {
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*schemaJSON `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *schemaJSON            `json:"items,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Const                any                    `json:"const,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Maximum              *float64               `json:"maximum,omitempty"`
+	MinLength            *int                   `json:"minLength,omitempty"`
+	MaxLength            *int                   `json:"maxLength,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Defs                 map[string]*schemaJSON `json:"$defs,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	AnyOf                []*schemaJSON          `json:"anyOf,omitempty"`
+	OneOf                []*schemaJSON          `json:"oneOf,omitempty"`
+	AllOf                []*schemaJSON          `json:"allOf,omitempty"`
+	Not                  *schemaJSON            `json:"not,omitempty"`
+}
+
+// MarshalJSON encodes the toolset name and every tool, sorted by ID, in a
+// versioned format (see FormatVersion). All CanonicalTool fields are kept;
+// schemas are written with JSON Schema keywords.
+func (ts *Toolset) MarshalJSON() ([]byte, error) {
+	snap := ts.Snapshot()
+	doc := toolsetJSON{
+		FormatVersion: FormatVersion,
+		Name:          snap.name,
+		Tools:         make([]*toolJSON, len(snap.tools)),
+	}
+	for i, t := range snap.tools {
+		doc.Tools[i] = encodeTool(t)
+	}
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON replaces the toolset's name and tools with those encoded by
+// MarshalJSON. The document is validated first: unknown fields, a missing or
+// newer format_version, tools without a name and duplicate tool IDs are
+// reported together as ValidationErrors; on error ts is unchanged.
+//
+// Values of type any (Enum, Const, Default and SourceMeta entries) decode as
+// encoding/json decodes into any, so numbers become float64. Nil and empty
+// lists are not distinguished.
+//
+// UnmarshalJSON is meant for toolsets that are not yet shared: it emits no
+// change events, and the name change is not synchronized with readers.
+func (ts *Toolset) UnmarshalJSON(data []byte) error {
+	tools, name, err := decodeToolset(data)
+	if err != nil {
+		return err
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	snap := newSnapshot(name, tools)
+	if old := ts.snap.Load(); old != nil {
+		snap.version = old.version + 1
+	}
+	ts.name = name
+	ts.snap.Store(snap)
+	return nil
+}
+
+// decodeToolset decodes and validates a serialized toolset.
+func decodeToolset(data []byte) ([]*tooladapter.CanonicalTool, string, error) {
+	var header struct {
+		FormatVersion *int `json:"format_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, "", jsonFieldError(data, err)
+	}
+	switch {
+	case header.FormatVersion == nil:
+		return nil, "", ValidationErrors{{Path: "format_version", Msg: "is required"}}
+	case *header.FormatVersion < 1 || *header.FormatVersion > FormatVersion:
+		return nil, "", ValidationErrors{{Path: "format_version", Msg: fmt.Sprintf("unsupported version %d (supported: 1 to %d)", *header.FormatVersion, FormatVersion)}}
+	}
+
+	var doc toolsetJSON
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, "", jsonFieldError(data, err)
+	}
+
+	var errs ValidationErrors
+	tools := make([]*tooladapter.CanonicalTool, 0, len(doc.Tools))
+	seen := make(map[string]int, len(doc.Tools))
+	for i, tj := range doc.Tools {
+		path := indexPath("tools", i)
+		if tj == nil {
+			errs = append(errs, &FieldError{Path: path, Msg: "must be an object"})
+			continue
+		}
+		t, err := tj.decode()
+		if err != nil {
+			errs = append(errs, &FieldError{Path: fieldPath(path, "timeout"), Msg: err.Error()})
+			continue
+		}
+		if t.Name == "" {
+			errs = append(errs, &FieldError{Path: fieldPath(path, "name"), Msg: "is required"})
+			continue
+		}
+		if first, ok := seen[t.ID()]; ok {
+			errs = append(errs, &FieldError{Path: path, Msg: fmt.Sprintf("duplicate tool ID %q (first at %s)", t.ID(), indexPath("tools", first))})
+			continue
+		}
+		seen[t.ID()] = i
+		tools = append(tools, t)
+	}
+	if err := errs.err(); err != nil {
+		return nil, "", err
+	}
+	return tools, doc.Name, nil
+}
+
+// jsonFieldError converts an encoding/json error into a FieldError,
+// locating it by path and line where encoding/json reports them.
+func jsonFieldError(data []byte, err error) error {
+	fe := &FieldError{Msg: strings.TrimPrefix(err.Error(), "json: ")}
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+		fe.Path = jsonPath(typeErr.Field)
+		fe.Msg = "must be " + jsonTypeName(typeErr.Type.Kind().String()) + ", found " + typeErr.Value
+	}
+	if offset > 0 && offset <= int64(len(data)) {
+		fe.Line = 1 + bytes.Count(data[:offset], []byte("\n"))
+		fe.Column = int(offset) - bytes.LastIndexByte(data[:offset], '\n') - 1
+	}
+	return ValidationErrors{fe}
+}
+
+// jsonPath converts an encoding/json field path such as "tools.0.name" to
+// the FieldError form "tools[0].name".
+func jsonPath(field string) string {
+	path := ""
+	for _, part := range strings.Split(field, ".") {
+		if i, err := strconv.Atoi(part); err == nil && path != "" {
+			path = indexPath(path, i)
+		} else {
+			path = fieldPath(path, part)
+		}
+	}
+	return path
+}
+
+// jsonTypeName names a Go kind in JSON terms for error messages.
+func jsonTypeName(kind string) string {
+	switch kind {
+	case "string":
+		return "a string"
+	case "int", "float64":
+		return "a number"
+	case "bool":
+		return "a boolean"
+	case "slice":
+		return "an array"
+	case "struct", "map", "ptr":
+		return "an object"
+	}
+	return kind
+}
+
+// encodeTool converts t to its serialized form.
+func encodeTool(t *tooladapter.CanonicalTool) *toolJSON {
+	tj := &toolJSON{
+		Namespace:      t.Namespace,
+		Name:           t.Name,
+		Version:        t.Version,
+		Description:    t.Description,
+		Category:       t.Category,
+		Tags:           t.Tags,
+		InputSchema:    encodeSchema(t.InputSchema),
+		OutputSchema:   encodeSchema(t.OutputSchema),
+		SourceFormat:   t.SourceFormat,
+		SourceMeta:     t.SourceMeta,
+		RequiredScopes: t.RequiredScopes,
+	}
+	if t.Timeout != 0 {
+		tj.Timeout = t.Timeout.String()
+	}
+	return tj
+}
+
+// decode converts tj to a CanonicalTool.
+func (tj *toolJSON) decode() (*tooladapter.CanonicalTool, error) {
+	t := &tooladapter.CanonicalTool{
+		Namespace:      tj.Namespace,
+		Name:           tj.Name,
+		Version:        tj.Version,
+		Description:    tj.Description,
+		Category:       tj.Category,
+		Tags:           tj.Tags,
+		InputSchema:    tj.InputSchema.decode(),
+		OutputSchema:   tj.OutputSchema.decode(),
+		SourceFormat:   tj.SourceFormat,
+		SourceMeta:     tj.SourceMeta,
+		RequiredScopes: tj.RequiredScopes,
+	}
+	if tj.Timeout != "" {
+		d, err := time.ParseDuration(tj.Timeout)
+		if err != nil {
+			return nil, errors.New("invalid duration " + strconv.Quote(tj.Timeout))
+		}
+		t.Timeout = d
+	}
+	return t, nil
+}
+
+// encodeSchema converts s to its serialized form.
+func encodeSchema(s *tooladapter.JSONSchema) *schemaJSON {
+	if s == nil {
+		return nil
+	}
+	return &schemaJSON{
+		Type:                 s.Type,
+		Properties:           mapSchemas(s.Properties, encodeSchema),
+		Required:             s.Required,
+		Items:                encodeSchema(s.Items),
+		Description:          s.Description,
+		Enum:                 s.Enum,
+		Const:                s.Const,
+		Default:              s.Default,
+		Minimum:              s.Minimum,
+		Maximum:              s.Maximum,
+		MinLength:            s.MinLength,
+		MaxLength:            s.MaxLength,
+		Pattern:              s.Pattern,
+		Format:               s.Format,
+		Ref:                  s.Ref,
+		Defs:                 mapSchemas(s.Defs, encodeSchema),
+		AdditionalProperties: s.AdditionalProperties,
+		AnyOf:                listSchemas(s.AnyOf, encodeSchema),
+		OneOf:                listSchemas(s.OneOf, encodeSchema),
+		AllOf:                listSchemas(s.AllOf, encodeSchema),
+		Not:                  encodeSchema(s.Not),
+	}
+}
+
+// decode converts sj to a JSONSchema.
+func (sj *schemaJSON) decode() *tooladapter.JSONSchema {
+	if sj == nil {
+		return nil
+	}
+	decode := (*schemaJSON).decode
+	return &tooladapter.JSONSchema{
+		Type:                 sj.Type,
+		Properties:           mapSchemas(sj.Properties, decode),
+		Required:             sj.Required,
+		Items:                sj.Items.decode(),
+		Description:          sj.Description,
+		Enum:                 sj.Enum,
+		Const:                sj.Const,
+		Default:              sj.Default,
+		Minimum:              sj.Minimum,
+		Maximum:              sj.Maximum,
+		MinLength:            sj.MinLength,
+		MaxLength:            sj.MaxLength,
+		Pattern:              sj.Pattern,
+		Format:               sj.Format,
+		Ref:                  sj.Ref,
+		Defs:                 mapSchemas(sj.Defs, decode),
+		AdditionalProperties: sj.AdditionalProperties,
+		AnyOf:                listSchemas(sj.AnyOf, decode),
+		OneOf:                listSchemas(sj.OneOf, decode),
+		AllOf:                listSchemas(sj.AllOf, decode),
+		Not:                  sj.Not.decode(),
+	}
+}
+
+// mapSchemas converts each schema in m, keeping nil for empty maps.
+func mapSchemas[From, To any](m map[string]*From, convert func(*From) *To) map[string]*To {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]*To, len(m))
+	for k, v := range m {
+		out[k] = convert(v)
+	}
+	return out
+}
+
+// listSchemas converts each schema in list, keeping nil for empty lists.
+func listSchemas[From, To any](list []*From, convert func(*From) *To) []*To {
+	if len(list) == 0 {
+		return nil
+	}
+	out := make([]*To, len(list))
+	for i, v := range list {
+		out[i] = convert(v)
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package toolset
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func richTool() *tooladapter.CanonicalTool {
+	minimum, maxLen, closed := 0.0, 64, false
+	return &tooladapter.CanonicalTool{
+		Namespace:    "github",
+		Name:         "search",
+		Version:      "1.2.0",
+		Description:  "Search code",
+		Category:     "read",
+		Tags:         []string{"search", "code"},
+		Timeout:      90 * time.Second,
+		SourceFormat: "mcp",
+		SourceMeta:   map[string]any{"title": "Search", "weight": 1.5},
+		InputSchema: &tooladapter.JSONSchema{
+			Type:                 "object",
+			Required:             []string{"q"},
+			AdditionalProperties: &closed,
+			Properties: map[string]*tooladapter.JSONSchema{
+				"q":    {Type: "string", MaxLength: &maxLen, Pattern: "^\\S", Default: "x"},
+				"page": {Type: "integer", Minimum: &minimum, Enum: []any{1.0, 2.0}},
+				"opts": {Ref: "#/$defs/Opts"},
+			},
+			Defs: map[string]*tooladapter.JSONSchema{
+				"Opts": {AnyOf: []*tooladapter.JSONSchema{{Type: "string"}, {Const: "all"}}, Not: &tooladapter.JSONSchema{Type: "null"}},
+			},
+		},
+		OutputSchema:   &tooladapter.JSONSchema{Type: "array", Items: &tooladapter.JSONSchema{Type: "string", Format: "uri"}},
+		RequiredScopes: []string{"repo:read"},
+	}
+}
+
+func TestToolset_MarshalJSON(t *testing.T) {
+	t.Run("round-trips every field", func(t *testing.T) {
+		ts := New("prod")
+		ts.Add(richTool())
+		ts.Add(makeTool("", "plain", nil))
+
+		data, err := json.Marshal(ts)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		var got Toolset
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if got.Name() != "prod" || !reflect.DeepEqual(got.IDs(), ts.IDs()) {
+			t.Fatalf("decoded %q %v", got.Name(), got.IDs())
+		}
+		decoded, _ := got.Get("github:search")
+		if !reflect.DeepEqual(decoded, richTool()) {
+			t.Errorf("decoded tool differs:\n got %+v\nwant %+v", decoded, richTool())
+		}
+		if got.Fingerprint() != ts.Fingerprint() || got.ETag() != ts.ETag() {
+			t.Error("fingerprint or ETag changed by round trip")
+		}
+	})
+
+	t.Run("uses a versioned, keyword-based format", func(t *testing.T) {
+		ts := New("fmt")
+		ts.Add(richTool())
+		data, _ := json.Marshal(ts)
+		for _, want := range []string{`"format_version":1`, `"name":"fmt"`, `"input_schema":{`, `"$defs":{`, `"maxLength":64`, `"timeout":"1m30s"`} {
+			if !strings.Contains(string(data), want) {
+				t.Errorf("output lacks %s: %s", want, data)
+			}
+		}
+	})
+
+	t.Run("decoding bumps the generation", func(t *testing.T) {
+		ts := pagedToolset("a")
+		gen := ts.Generation()
+		if err := json.Unmarshal([]byte(`{"format_version":1,"name":"x","tools":[]}`), ts); err != nil {
+			t.Fatal(err)
+		}
+		if ts.Generation() <= gen || ts.Count() != 0 || ts.Name() != "x" {
+			t.Errorf("after decode: generation %d, count %d, name %q", ts.Generation(), ts.Count(), ts.Name())
+		}
+	})
+}
+
+func TestToolset_UnmarshalJSON_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"missing version", `{"name":"x","tools":[]}`, []string{"format_version: is required"}},
+		{"newer version", `{"format_version":2,"tools":[]}`, []string{"format_version: unsupported version 2 (supported: 1 to 1)"}},
+		{"syntax", "{\"format_version\":1,\n\"tools\": [}", []string{"document (line 2): invalid character '}' looking for beginning of value"}},
+		{"wrong type", `{"format_version":1,"tools":[{"name":7}]}`, []string{"tools[0].name (line 1): must be a string, found number"}},
+		{"unknown field", `{"format_version":1,"tools":[{"name":"a","owner":"me"}]}`, []string{`document: unknown field "owner"`}},
+		{"semantic", `{"format_version":1,"tools":[{"name":""},null,{"name":"a","timeout":"soon"},{"name":"b"},{"name":"b"}]}`, []string{
+			"tools[0].name: is required",
+			"tools[1]: must be an object",
+			`tools[2].timeout: invalid duration "soon"`,
+			`tools[4]: duplicate tool ID "b" (first at tools[3])`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := pagedToolset("keep")
+			err := ts.UnmarshalJSON([]byte(tt.doc))
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) {
+				t.Fatalf("err = %v, want ValidationErrors", err)
+			}
+			var got []string
+			for _, e := range verrs {
+				got = append(got, e.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %q, want %q", got, tt.want)
+			}
+			if ts.Count() != 1 {
+				t.Error("toolset changed despite error")
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package toolset
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// Pattern filters match tool attributes against glob or regular expression
+// patterns. Patterns are compiled once, when the filter is constructed, and
+// must match the whole value.
+//
+// Glob syntax:
+//
+//	*        any sequence of characters, including ":" and "."
+//	?        any single character
+//	[abc]    any character in the class; ranges like [a-z] are allowed
+//	[!abc]   any character not in the class
+//	\x       the character x literally
+//
+// Regular expressions use RE2 syntax (package regexp) and are anchored at
+// both ends, so "delete_.*" matches "delete_repo" but not "undelete_repo".
+
+// PatternError reports an invalid glob or regular expression.
+type PatternError struct {
+	// Syntax is "glob" or "regex".
+	Syntax string
+
+	// Pattern is the offending pattern.
+	Pattern string
+
+	// Msg describes the problem.
+	Msg string
+}
+
+func (e *PatternError) Error() string {
+	return "invalid " + e.Syntax + " " + quoteValue(e.Pattern) + ": " + e.Msg
+}
+
+// GlobIDs returns a filter matching tools whose ID matches any glob.
+func GlobIDs(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileGlobs, patterns, toolID)
+}
+
+// GlobNames returns a filter matching tools whose name matches any glob.
+func GlobNames(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileGlobs, patterns, toolName)
+}
+
+// GlobNamespaces returns a filter matching tools whose namespace matches any glob.
+func GlobNamespaces(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileGlobs, patterns, toolNamespace)
+}
+
+// GlobTags returns a filter matching tools with any tag matching any glob.
+func GlobTags(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileGlobs, patterns, toolTags)
+}
+
+// GlobCategories returns a filter matching tools whose category matches any glob.
+func GlobCategories(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileGlobs, patterns, toolCategory)
+}
+
+// RegexIDs returns a filter matching tools whose ID matches any expression.
+func RegexIDs(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileRegexps, patterns, toolID)
+}
+
+// RegexNames returns a filter matching tools whose name matches any expression.
+func RegexNames(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileRegexps, patterns, toolName)
+}
+
+// RegexNamespaces returns a filter matching tools whose namespace matches any expression.
+func RegexNamespaces(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileRegexps, patterns, toolNamespace)
+}
+
+// RegexTags returns a filter matching tools with any tag matching any expression.
+func RegexTags(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileRegexps, patterns, toolTags)
+}
+
+// RegexCategories returns a filter matching tools whose category matches any expression.
+func RegexCategories(patterns ...string) (FilterFunc, error) {
+	return patternFilter(compileRegexps, patterns, toolCategory)
+}
+
+// Attribute accessors shared by pattern filters and RBAC.
+func toolID(t *tooladapter.CanonicalTool) []string        { return []string{t.ID()} }
+func toolName(t *tooladapter.CanonicalTool) []string      { return []string{t.Name} }
+func toolNamespace(t *tooladapter.CanonicalTool) []string { return []string{t.Namespace} }
+func toolTags(t *tooladapter.CanonicalTool) []string      { return t.Tags }
+func toolCategory(t *tooladapter.CanonicalTool) []string  { return []string{t.Category} }
+
+// patternFilter compiles patterns and returns a filter matching tools with
+// any attribute value matching any pattern.
+func patternFilter(compile func([]string) (*patternSet, error), patterns []string, get func(*tooladapter.CanonicalTool) []string) (FilterFunc, error) {
+	set, err := compile(patterns)
+	if err != nil {
+		return nil, err
+	}
+	return func(t *tooladapter.CanonicalTool) bool {
+		if t == nil {
+			return false
+		}
+		_, ok := set.matchAny(get(t))
+		return ok
+	}, nil
+}
+
+// patternSet is a compiled list of patterns. Patterns without
+// metacharacters are matched by set lookup.
+type patternSet struct {
+	literals map[string]bool
+	regexps  []*regexp.Regexp
+}
+
+// match reports whether s matches any pattern.
+func (ps *patternSet) match(s string) bool {
+	if ps.literals[s] {
+		return true
+	}
+	for _, re := range ps.regexps {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAny returns the first value matching any pattern.
+func (ps *patternSet) matchAny(values []string) (string, bool) {
+	for _, v := range values {
+		if ps.match(v) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// compileGlobs compiles glob patterns.
+func compileGlobs(patterns []string) (*patternSet, error) {
+	ps := &patternSet{literals: make(map[string]bool)}
+	for _, p := range patterns {
+		if !strings.ContainsAny(p, `*?[\`) {
+			ps.literals[p] = true
+			continue
+		}
+		expr, err := globToRegexp(p)
+		if err != nil {
+			return nil, err
+		}
+		ps.regexps = append(ps.regexps, regexp.MustCompile(expr))
+	}
+	return ps, nil
+}
+
+// compileRegexps compiles anchored regular expressions.
+func compileRegexps(patterns []string) (*patternSet, error) {
+	ps := &patternSet{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(`^(?:` + p + `)$`)
+		if err != nil {
+			msg := err.Error()
+			var syntaxErr *syntax.Error
+			if errors.As(err, &syntaxErr) {
+				msg = syntaxErr.Code.String() + " " + quoteValue(syntaxErr.Expr)
+			}
+			return nil, &PatternError{Syntax: "regex", Pattern: p, Msg: msg}
+		}
+		ps.regexps = append(ps.regexps, re)
+	}
+	return ps, nil
+}
+
+// globToRegexp translates a glob into an anchored regular expression.
+func globToRegexp(glob string) (string, error) {
+	var b strings.Builder
+	b.WriteString(`^(?s:`)
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*':
+			b.WriteString(`.*`)
+		case '?':
+			b.WriteString(`.`)
+		case '\\':
+			if i+1 == len(glob) {
+				return "", &PatternError{Syntax: "glob", Pattern: glob, Msg: "trailing backslash"}
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '[':
+			end := i + 1
+			if end < len(glob) && glob[end] == '!' {
+				end++
+			}
+			if end < len(glob) && glob[end] == ']' {
+				end++ // a leading ] is literal
+			}
+			for end < len(glob) && glob[end] != ']' {
+				end++
+			}
+			if end == len(glob) {
+				return "", &PatternError{Syntax: "glob", Pattern: glob, Msg: "missing ]"}
+			}
+			class := glob[i+1 : end]
+			b.WriteByte('[')
+			if strings.HasPrefix(class, "!") {
+				b.WriteByte('^')
+				class = class[1:]
+			}
+			for j := 0; j < len(class); j++ {
+				switch {
+				case class[j] != '-':
+					b.WriteString(regexp.QuoteMeta(class[j : j+1]))
+				case j > 0 && j < len(class)-1:
+					b.WriteByte('-') // range
+				default:
+					b.WriteString(`\-`)
+				}
+			}
+			b.WriteByte(']')
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	b.WriteString(`)$`)
+	if _, err := regexp.Compile(b.String()); err != nil {
+		return "", &PatternError{Syntax: "glob", Pattern: glob, Msg: "invalid character class"}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,85 @@
+package toolset
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func TestPatternFilters(t *testing.T) {
+	tools := []*tooladapter.CanonicalTool{
+		makeTool("github", "create_issue", []string{"write"}),
+		makeTool("github", "delete_repo", []string{"write", "dangerous"}),
+		makeTool("gitlab", "delete_branch", []string{"write"}),
+		makeTool("slack.v2", "send", []string{"chat"}),
+		{Name: "search", Category: "read-only"},
+	}
+	matching := func(fn FilterFunc) []string {
+		var ids []string
+		for _, tool := range tools {
+			if fn(tool) {
+				ids = append(ids, tool.ID())
+			}
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		build    func(...string) (FilterFunc, error)
+		patterns []string
+		want     []string
+	}{
+		{"glob ids", GlobIDs, []string{"github:*"}, []string{"github:create_issue", "github:delete_repo"}},
+		{"glob ids across namespaces", GlobIDs, []string{"*:delete_*"}, []string{"github:delete_repo", "gitlab:delete_branch"}},
+		{"glob literal", GlobIDs, []string{"search"}, []string{"search"}},
+		{"glob names", GlobNames, []string{"?e*"}, []string{"github:delete_repo", "gitlab:delete_branch", "slack.v2:send", "search"}},
+		{"glob namespaces class", GlobNamespaces, []string{"git[!h]ab"}, []string{"gitlab:delete_branch"}},
+		{"glob namespaces range", GlobNamespaces, []string{"git[h-l]*"}, []string{"github:create_issue", "github:delete_repo", "gitlab:delete_branch"}},
+		{"glob escaped dot", GlobNamespaces, []string{`slack\.v?`}, []string{"slack.v2:send"}},
+		{"glob tags", GlobTags, []string{"dang*"}, []string{"github:delete_repo"}},
+		{"glob categories", GlobCategories, []string{"read-*"}, []string{"search"}},
+		{"regex ids are anchored", RegexIDs, []string{"delete"}, nil},
+		{"regex ids", RegexIDs, []string{".*:delete_.*"}, []string{"github:delete_repo", "gitlab:delete_branch"}},
+		{"regex names", RegexNames, []string{"create_.*", "send"}, []string{"github:create_issue", "slack.v2:send"}},
+		{"regex namespaces", RegexNamespaces, []string{`slack\.v\d+`}, []string{"slack.v2:send"}},
+		{"regex tags", RegexTags, []string{"ch.t"}, []string{"slack.v2:send"}},
+		{"regex categories", RegexCategories, []string{"read-(only|write)"}, []string{"search"}},
+		{"no patterns", GlobIDs, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := tt.build(tt.patterns...)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got := matching(fn); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+			if fn(nil) {
+				t.Error("nil tool should not match")
+			}
+		})
+	}
+
+	t.Run("invalid patterns are errors", func(t *testing.T) {
+		for _, tc := range []struct {
+			build   func(...string) (FilterFunc, error)
+			pattern string
+		}{
+			{GlobIDs, "github:[a"},
+			{GlobIDs, `trailing\`},
+			{GlobNames, "[z-a]"},
+			{RegexIDs, "(unclosed"},
+			{RegexTags, "a**"},
+		} {
+			fn, err := tc.build("ok", tc.pattern)
+			var patternErr *PatternError
+			if fn != nil || !errors.As(err, &patternErr) || patternErr.Pattern != tc.pattern {
+				t.Errorf("%q: fn = %v, err = %v; want *PatternError", tc.pattern, fn != nil, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package toolset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// MultiExposure exports one Toolset to several adapters in a single pass.
+type MultiExposure struct {
+	toolset   *Toolset
+	exposures []*Exposure
+}
+
+// NewMultiExposure creates a MultiExposure for the given toolset and adapters.
+// Adapter names must be unique; this is checked by Export.
+func NewMultiExposure(ts *Toolset, adapters ...tooladapter.Adapter) *MultiExposure {
+	m := &MultiExposure{toolset: ts}
+	for _, a := range adapters {
+		m.exposures = append(m.exposures, NewExposure(ts, a))
+	}
+	return m
+}
+
+// Configure applies fn to the Exposure of every adapter, e.g.
+//
+//	m.Configure(func(e *Exposure) { e.WithSchemaLowering(true) })
+//
+// It returns m for chaining.
+func (m *MultiExposure) Configure(fn func(*Exposure)) *MultiExposure {
+	for _, e := range m.exposures {
+		fn(e)
+	}
+	return m
+}
+
+// Manifest is the result of a MultiExposure export.
+type Manifest struct {
+	// Toolset is the name of the exported toolset.
+	Toolset string `json:"toolset"`
+
+	// Tools lists the IDs of every tool in the exported snapshot.
+	Tools []string `json:"tools"`
+
+	// Protocols holds each adapter's export, keyed by adapter name.
+	Protocols map[string]*ProtocolExport `json:"protocols"`
+
+	// Partial lists tools that converted for some adapters but failed for
+	// others, sorted by ID.
+	Partial []PartialExport `json:"partial"`
+}
+
+// ProtocolExport is one adapter's share of a Manifest.
+type ProtocolExport struct {
+	// Adapter is the adapter name.
+	Adapter string `json:"adapter"`
+
+	// Tools holds the converted tools, index-aligned with ToolIDs.
+	Tools   []any    `json:"tools"`
+	ToolIDs []string `json:"toolIds"`
+
+	// Warnings and Errors are as returned by ExportWithSchemaWarnings.
+	// Errors are encoded as [{"tool": id, "error": message}], with "tool"
+	// omitted for errors not tied to a tool.
+	Warnings []SchemaWarning `json:"warnings"`
+	Errors   []error         `json:"-"`
+
+	// Names maps exported names to tool IDs when a naming strategy is
+	// configured; nil otherwise.
+	Names *NameMap `json:"-"`
+
+	// Hash is "sha256:" followed by the hex digest of the JSON encoding of
+	// Tools. It changes exactly when the exported payload changes.
+	Hash string `json:"hash"`
+}
+
+// exportErrorJSON is the JSON form of a ProtocolExport error.
+type exportErrorJSON struct {
+	Tool  string `json:"tool,omitempty"`
+	Error string `json:"error"`
+}
+
+// MarshalJSON implements json.Marshaler, encoding Errors as described on
+// the field.
+func (p ProtocolExport) MarshalJSON() ([]byte, error) {
+	type plain ProtocolExport
+	errs := make([]exportErrorJSON, len(p.Errors))
+	for i, err := range p.Errors {
+		errs[i].Error = err.Error()
+		var convErr *ConversionError
+		if errors.As(err, &convErr) {
+			errs[i].Tool = convErr.ToolID
+		}
+	}
+	return json.Marshal(struct {
+		plain
+		Errors []exportErrorJSON `json:"errors"`
+	}{plain(p), errs})
+}
+
+// PartialExport flags a tool that failed for only some adapters.
+type PartialExport struct {
+	ID string `json:"id"`
+
+	// Failed and Exported list adapter names in the order given to
+	// NewMultiExposure.
+	Failed   []string `json:"failed"`
+	Exported []string `json:"exported"`
+}
+
+// Adapters returns the adapter names in the manifest, sorted.
+func (m *Manifest) Adapters() []string {
+	names := make([]string, 0, len(m.Protocols))
+	for name := range m.Protocols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Export converts a single snapshot of the toolset with every adapter.
+//
+// Conversion failures do not stop the export; they are reported per
+// protocol and, when a tool fails for some adapters only, in Manifest.Partial.
+// Export returns an error only for invalid configuration: no adapters, a nil
+// adapter, an invalid naming strategy, or two adapters with the same name.
+func (m *MultiExposure) Export() (*Manifest, error) {
+	if len(m.exposures) == 0 {
+		return nil, errors.New("no adapters")
+	}
+	seen := make(map[string]bool, len(m.exposures))
+	for i, e := range m.exposures {
+		if err := e.check(); err != nil {
+			return nil, fmt.Errorf("adapter %d: %w", i, err)
+		}
+		name := e.adapter.Name()
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate adapter name %q", name)
+		}
+		seen[name] = true
+	}
+
+	tools := m.toolset.Tools()
+	manifest := &Manifest{
+		Toolset:   m.toolset.Name(),
+		Tools:     make([]string, len(tools)),
+		Protocols: make(map[string]*ProtocolExport, len(m.exposures)),
+		Partial:   []PartialExport{},
+	}
+	for i, t := range tools {
+		manifest.Tools[i] = t.ID()
+	}
+
+	exported := make(map[string][]string) // tool ID -> adapters
+	failed := make(map[string][]string)
+	for _, e := range m.exposures {
+		name := e.adapter.Name()
+		res := e.export(tools)
+		p := &ProtocolExport{
+			Adapter:  name,
+			Tools:    res.tools,
+			ToolIDs:  res.ids,
+			Names:    res.names,
+			Warnings: res.warnings,
+			Errors:   res.errs,
+		}
+		if p.Warnings == nil {
+			p.Warnings = []SchemaWarning{}
+		}
+		hash, err := hashExport(res.tools)
+		if err != nil {
+			p.Errors = append(p.Errors, fmt.Errorf("hash %s export: %w", name, err))
+		}
+		p.Hash = hash
+		manifest.Protocols[name] = p
+
+		for _, id := range res.ids {
+			exported[id] = append(exported[id], name)
+		}
+		for _, err := range res.errs {
+			var convErr *ConversionError
+			if errors.As(err, &convErr) {
+				failed[convErr.ToolID] = append(failed[convErr.ToolID], name)
+			}
+		}
+	}
+
+	for _, id := range manifest.Tools {
+		if len(failed[id]) > 0 && len(exported[id]) > 0 {
+			manifest.Partial = append(manifest.Partial, PartialExport{ID: id, Failed: failed[id], Exported: exported[id]})
+		}
+	}
+	return manifest, nil
+}
+
+// hashExport returns the content hash of converted tools.
+func hashExport(tools []any) (string, error) {
+	data, err := json.Marshal(tools)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,123 @@
+package toolset
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func TestMultiExposure_Export(t *testing.T) {
+	newToolset := func() *Toolset {
+		ts := New("multi")
+		ts.Add(&tooladapter.CanonicalTool{
+			Namespace:   "ns",
+			Name:        "search",
+			InputSchema: &tooladapter.JSONSchema{Type: "string", Pattern: "^q"},
+		})
+		ts.Add(makeTool("ns", "fetch", nil))
+		return ts
+	}
+	full := &mockAdapter{name: "full"}
+	strict := &selectiveErrorAdapter{
+		name: "strict",
+		fromCanonical: func(tool *tooladapter.CanonicalTool) (any, error) {
+			if tool.Name == "search" {
+				return nil, errors.New("unsupported")
+			}
+			return map[string]any{"name": tool.Name}, nil
+		},
+	}
+	noPattern := &mockAdapter{name: "lite", supportedFeatures: map[tooladapter.SchemaFeature]bool{}}
+
+	t.Run("exports every adapter from one snapshot", func(t *testing.T) {
+		m, err := NewMultiExposure(newToolset(), full, strict, noPattern).Export()
+		if err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		if m.Toolset != "multi" || !reflect.DeepEqual(m.Tools, []string{"ns:fetch", "ns:search"}) {
+			t.Errorf("manifest = %q %v", m.Toolset, m.Tools)
+		}
+		if got := m.Adapters(); !reflect.DeepEqual(got, []string{"full", "lite", "strict"}) {
+			t.Errorf("Adapters() = %v", got)
+		}
+		if p := m.Protocols["full"]; len(p.Tools) != 2 || len(p.Errors) != 0 || len(p.Warnings) != 0 {
+			t.Errorf("full = %+v", p)
+		}
+		if p := m.Protocols["lite"]; len(p.Warnings) != 1 || p.Warnings[0].Pointer != "/pattern" {
+			t.Errorf("lite warnings = %v", p.Warnings)
+		}
+		if p := m.Protocols["strict"]; !reflect.DeepEqual(p.ToolIDs, []string{"ns:fetch"}) || len(p.Errors) != 1 {
+			t.Errorf("strict = %+v", p)
+		}
+	})
+
+	t.Run("errors are serialized", func(t *testing.T) {
+		m, _ := NewMultiExposure(newToolset(), full, strict).Export()
+		data, err := json.Marshal(m)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		var decoded struct {
+			Protocols map[string]struct {
+				Errors []map[string]string `json:"errors"`
+			} `json:"protocols"`
+		}
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		errs := decoded.Protocols["strict"].Errors
+		if len(errs) != 1 || errs[0]["tool"] != "ns:search" || errs[0]["error"] != m.Protocols["strict"].Errors[0].Error() {
+			t.Errorf("strict errors = %v", errs)
+		}
+		if errs := decoded.Protocols["full"].Errors; errs == nil || len(errs) != 0 {
+			t.Errorf("full errors = %v, want []", errs)
+		}
+	})
+
+	t.Run("flags partial failures", func(t *testing.T) {
+		m, _ := NewMultiExposure(newToolset(), full, strict).Export()
+		want := []PartialExport{{ID: "ns:search", Failed: []string{"strict"}, Exported: []string{"full"}}}
+		if !reflect.DeepEqual(m.Partial, want) {
+			t.Errorf("Partial = %+v, want %+v", m.Partial, want)
+		}
+	})
+
+	t.Run("hash is stable and content addressed", func(t *testing.T) {
+		a, _ := NewMultiExposure(newToolset(), full, strict).Export()
+		b, _ := NewMultiExposure(newToolset(), full, strict).Export()
+		if !strings.HasPrefix(a.Protocols["full"].Hash, "sha256:") {
+			t.Errorf("Hash = %q", a.Protocols["full"].Hash)
+		}
+		if a.Protocols["full"].Hash != b.Protocols["full"].Hash {
+			t.Error("hash differs for identical exports")
+		}
+		if a.Protocols["full"].Hash == a.Protocols["strict"].Hash {
+			t.Error("hash should differ for different payloads")
+		}
+	})
+
+	t.Run("Configure applies to every adapter", func(t *testing.T) {
+		m, _ := NewMultiExposure(newToolset(), full, noPattern).
+			Configure(func(e *Exposure) { e.WithSchemaLowering(true) }).
+			Export()
+		if w := m.Protocols["lite"].Warnings; len(w) != 1 || w[0].Action != LoweringDescribed {
+			t.Errorf("lite warnings = %v, want lowered pattern", w)
+		}
+	})
+
+	t.Run("invalid configuration", func(t *testing.T) {
+		if _, err := NewMultiExposure(newToolset()).Export(); err == nil {
+			t.Error("Export() without adapters should fail")
+		}
+		if _, err := NewMultiExposure(newToolset(), full, nil).Export(); err == nil {
+			t.Error("Export() with nil adapter should fail")
+		}
+		if _, err := NewMultiExposure(newToolset(), full, &mockAdapter{name: "full"}).Export(); err == nil {
+			t.Error("Export() with duplicate adapter names should fail")
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package toolset
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// DefaultMaxNameLength is the tool name limit of the OpenAI and Anthropic APIs.
+const DefaultMaxNameLength = 64
+
+// nameHashLength is the number of hex digits in a truncation suffix.
+const nameHashLength = 8
+
+// NamingStrategy maps canonical tool IDs to provider-safe tool names
+// matching ^[a-zA-Z0-9_-]{1,MaxLength}$.
+//
+// The exported name is Prefix + namespace + Separator + name + Suffix
+// (without namespace and Separator for tools that have none). Every
+// character outside [a-zA-Z0-9_-] is replaced with "_". Names longer than
+// MaxLength are truncated and end with "_" and the first 8 hex digits of the
+// SHA-256 of the tool ID, so truncated names stay stable and distinct.
+type NamingStrategy struct {
+	// Separator joins namespace and name. Defaults to "__".
+	Separator string
+
+	// MaxLength is the maximum name length. Defaults to DefaultMaxNameLength.
+	MaxLength int
+
+	// Prefix and Suffix are added to every name and never truncated.
+	Prefix string
+	Suffix string
+}
+
+// Validate reports whether the strategy can produce valid names.
+func (s NamingStrategy) Validate() error {
+	if s.MaxLength < 0 {
+		return fmt.Errorf("naming: MaxLength %d is negative", s.MaxLength)
+	}
+	fixed := len(s.Prefix) + len(s.Suffix) + 1 + nameHashLength
+	if limit := s.maxLength(); fixed >= limit {
+		return fmt.Errorf("naming: prefix and suffix leave no room for names within %d characters", limit)
+	}
+	return nil
+}
+
+// Name returns the exported name for a tool.
+func (s NamingStrategy) Name(t *tooladapter.CanonicalTool) string {
+	base := t.Name
+	if t.Namespace != "" {
+		sep := s.Separator
+		if sep == "" {
+			sep = "__"
+		}
+		base = t.Namespace + sep + t.Name
+	}
+	prefix, suffix := sanitizeName(s.Prefix), sanitizeName(s.Suffix)
+	base = sanitizeName(base)
+
+	limit := s.maxLength()
+	if base != "" && len(prefix)+len(base)+len(suffix) <= limit {
+		return prefix + base + suffix
+	}
+	sum := sha256.Sum256([]byte(t.ID()))
+	hash := hex.EncodeToString(sum[:])[:nameHashLength]
+	keep := limit - len(prefix) - len(suffix) - 1 - nameHashLength
+	if keep > len(base) {
+		keep = len(base)
+	}
+	if keep < 0 {
+		keep = 0
+	}
+	return prefix + base[:keep] + "_" + hash + suffix
+}
+
+func (s NamingStrategy) maxLength() int {
+	if s.MaxLength == 0 {
+		return DefaultMaxNameLength
+	}
+	return s.MaxLength
+}
+
+// sanitizeName replaces characters outside [a-zA-Z0-9_-] with "_".
+func sanitizeName(s string) string {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '_', c == '-':
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte('_')
+		}
+	}
+	return sb.String()
+}
+
+// NameCollisionError reports a tool whose exported name is already taken.
+type NameCollisionError struct {
+	// Name is the contested exported name.
+	Name string
+
+	// ID is the tool that was omitted; Existing is the tool that kept Name.
+	ID       string
+	Existing string
+}
+
+func (e *NameCollisionError) Error() string {
+	return fmt.Sprintf("exported name %q of %s collides with %s", e.Name, e.ID, e.Existing)
+}
+
+// NameMap maps exported tool names to canonical IDs and back.
+type NameMap struct {
+	byName map[string]string
+	byID   map[string]string
+}
+
+// ID returns the canonical ID for an exported name.
+func (m *NameMap) ID(name string) (string, bool) {
+	id, ok := m.byName[name]
+	return id, ok
+}
+
+// Name returns the exported name for a canonical ID.
+func (m *NameMap) Name(id string) (string, bool) {
+	name, ok := m.byID[id]
+	return name, ok
+}
+
+// Len returns the number of mapped tools.
+func (m *NameMap) Len() int {
+	return len(m.byName)
+}
+
+// Names returns all exported names, sorted.
+func (m *NameMap) Names() []string {
+	names := make([]string, 0, len(m.byName))
+	for name := range m.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// assignNames names tools in order. A tool whose name is already taken is
+// left out of the map and reported with a NameCollisionError.
+func assignNames(nameOf func(*tooladapter.CanonicalTool) string, tools []*tooladapter.CanonicalTool) (*NameMap, map[string]error) {
+	m := &NameMap{byName: make(map[string]string, len(tools)), byID: make(map[string]string, len(tools))}
+	collisions := make(map[string]error)
+	for _, t := range tools {
+		name := nameOf(t)
+		if existing, ok := m.byName[name]; ok {
+			collisions[t.ID()] = &NameCollisionError{Name: name, ID: t.ID(), Existing: existing}
+			continue
+		}
+		m.byName[name] = t.ID()
+		m.byID[t.ID()] = name
+	}
+	return m, collisions
+}
+
+// WithNaming makes the Exposure export tools under names produced by s.
+// Adapters receive a copy of each tool with Name set to the exported name
+// and Namespace cleared. Tools whose names collide after sanitization are
+// omitted and reported as errors. It returns e for chaining.
+func (e *Exposure) WithNaming(s NamingStrategy) *Exposure {
+	e.naming = &s
+	return e
+}
+
+// Names returns the exported name mapping for the current toolset and any
+// collision errors. Without a naming strategy, adapters export the bare
+// tool Name, so tools sharing a Name across namespaces collide.
+func (e *Exposure) Names() (*NameMap, []error) {
+	if e.naming == nil {
+		m, collisions := assignNames(bareName, e.toolset.Tools())
+		return m, collisionErrors(collisions)
+	}
+	if err := e.naming.Validate(); err != nil {
+		return nil, []error{err}
+	}
+	m, collisions := assignNames(e.naming.Name, e.toolset.Tools())
+	return m, collisionErrors(collisions)
+}
+
+func bareName(t *tooladapter.CanonicalTool) string {
+	return t.Name
+}
+
+// collisionErrors returns the collision errors sorted by tool ID.
+func collisionErrors(collisions map[string]error) []error {
+	ids := make([]string, 0, len(collisions))
+	for id := range collisions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		errs[i] = collisions[id]
+	}
+	return errs
+}
+
+// rename returns a copy of t exported under name.
+func rename(t *tooladapter.CanonicalTool, name string) *tooladapter.CanonicalTool {
+	c := *t
+	c.Name = name
+	c.Namespace = ""
+	return &c
+}
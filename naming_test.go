@@ -0,0 +1,156 @@
+package toolset
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+var providerName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+func TestNamingStrategy_Name(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy NamingStrategy
+		tool     *tooladapter.CanonicalTool
+		want     string
+	}{
+		{"default separator", NamingStrategy{}, makeTool("github", "create_issue", nil), "github__create_issue"},
+		{"custom separator", NamingStrategy{Separator: "-"}, makeTool("github", "create_issue", nil), "github-create_issue"},
+		{"no namespace", NamingStrategy{}, makeTool("", "search", nil), "search"},
+		{"invalid characters", NamingStrategy{}, makeTool("web.v2", "fetch page", nil), "web_v2__fetch_page"},
+		{"prefix and suffix", NamingStrategy{Prefix: "mcp_", Suffix: "_v1"}, makeTool("ns", "a", nil), "mcp_ns__a_v1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.strategy.Name(tt.tool); got != tt.want {
+				t.Errorf("Name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("truncates with a stable hash suffix", func(t *testing.T) {
+		s := NamingStrategy{Prefix: "p_"}
+		long := makeTool("namespace", strings.Repeat("x", 80), nil)
+		got := s.Name(long)
+		if len(got) != DefaultMaxNameLength || !providerName.MatchString(got) {
+			t.Errorf("Name() = %q (len %d), want valid 64-char name", got, len(got))
+		}
+		if !strings.HasPrefix(got, "p_namespace__xxx") {
+			t.Errorf("Name() = %q, want prefix preserved", got)
+		}
+		if again := s.Name(long); again != got {
+			t.Errorf("Name() not stable: %q != %q", again, got)
+		}
+		other := makeTool("namespace", strings.Repeat("x", 81), nil)
+		if s.Name(other) == got {
+			t.Error("different IDs should get different truncated names")
+		}
+	})
+
+	t.Run("respects MaxLength", func(t *testing.T) {
+		got := NamingStrategy{MaxLength: 20}.Name(makeTool("namespace", "a_long_tool_name", nil))
+		if len(got) != 20 {
+			t.Errorf("Name() = %q (len %d), want 20", got, len(got))
+		}
+	})
+}
+
+func TestNamingStrategy_Validate(t *testing.T) {
+	if err := (NamingStrategy{}).Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if err := (NamingStrategy{MaxLength: -1}).Validate(); err == nil {
+		t.Error("negative MaxLength should be invalid")
+	}
+	if err := (NamingStrategy{MaxLength: 12, Prefix: "abc"}).Validate(); err == nil {
+		t.Error("prefix leaving no room should be invalid")
+	}
+}
+
+func TestExposure_WithNaming(t *testing.T) {
+	t.Run("exports sanitized names and maps them back", func(t *testing.T) {
+		ts := New("test")
+		ts.Add(makeTool("github", "create_issue", nil))
+		ts.Add(makeTool("slack", "send", nil))
+		adapter := newCaptureAdapter()
+		exp := NewExposure(ts, adapter).WithNaming(NamingStrategy{})
+
+		if _, err := exp.Export(); err != nil {
+			t.Fatalf("Export() error = %v", err)
+		}
+		var names []string
+		for _, tool := range adapter.got {
+			if tool.Namespace != "" {
+				t.Errorf("Namespace = %q, want cleared", tool.Namespace)
+			}
+			names = append(names, tool.Name)
+		}
+		if !reflect.DeepEqual(names, []string{"github__create_issue", "slack__send"}) {
+			t.Errorf("exported names = %v", names)
+		}
+		if orig, _ := ts.Get("github:create_issue"); orig.Name != "create_issue" {
+			t.Error("original tool was modified")
+		}
+
+		m, errs := exp.Names()
+		if len(errs) != 0 {
+			t.Fatalf("Names() errs = %v", errs)
+		}
+		if id, ok := m.ID("slack__send"); !ok || id != "slack:send" {
+			t.Errorf("ID(slack__send) = %q, %v", id, ok)
+		}
+		if name, ok := m.Name("github:create_issue"); !ok || name != "github__create_issue" {
+			t.Errorf("Name(github:create_issue) = %q, %v", name, ok)
+		}
+		if m.Len() != 2 || !reflect.DeepEqual(m.Names(), []string{"github__create_issue", "slack__send"}) {
+			t.Errorf("Names() = %v", m.Names())
+		}
+	})
+
+	t.Run("reports collisions and omits the later tool", func(t *testing.T) {
+		ts := New("test")
+		ts.Add(makeTool("web.v2", "fetch", nil))
+		ts.Add(makeTool("web_v2", "fetch", nil))
+		exp := NewExposure(ts, newCaptureAdapter()).WithNaming(NamingStrategy{})
+
+		result, _, errs := exp.ExportWithSchemaWarnings()
+		if len(result) != 1 || len(errs) != 1 {
+			t.Fatalf("result = %d, errs = %v; want 1 and 1 collision", len(result), errs)
+		}
+		var collision *NameCollisionError
+		if !errors.As(errs[0], &collision) {
+			t.Fatalf("err = %v, want NameCollisionError", errs[0])
+		}
+		if collision.Name != "web_v2__fetch" || collision.ID != "web_v2:fetch" || collision.Existing != "web.v2:fetch" {
+			t.Errorf("collision = %+v", collision)
+		}
+		if _, err := exp.Export(); !errors.As(err, &collision) {
+			t.Errorf("Export() error = %v, want NameCollisionError", err)
+		}
+		if _, errs := exp.Names(); len(errs) != 1 {
+			t.Errorf("Names() errs = %v, want 1", errs)
+		}
+	})
+
+	t.Run("without strategy names are bare tool names", func(t *testing.T) {
+		ts := New("test")
+		ts.Add(makeTool("a", "send", nil))
+		ts.Add(makeTool("b", "send", nil))
+		m, errs := NewExposure(ts, newCaptureAdapter()).Names()
+		if id, _ := m.ID("send"); id != "a:send" || len(errs) != 1 {
+			t.Errorf("ID(send) = %q, errs = %v", id, errs)
+		}
+	})
+
+	t.Run("invalid strategy is a configuration error", func(t *testing.T) {
+		exp := NewExposure(New("test"), newCaptureAdapter()).WithNaming(NamingStrategy{MaxLength: 4})
+		if _, err := exp.Export(); err == nil {
+			t.Error("Export() should fail for invalid strategy")
+		}
+	})
+}
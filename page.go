@@ -0,0 +1,159 @@
+package toolset
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// ErrInvalidCursor is returned for page cursors that are malformed, were
+// issued by another toolset or key, or have been modified.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	cursorVersion = 1
+	cursorMACSize = 16
+)
+
+// Page returns up to limit tools, sorted by ID, starting after cursor.
+// An empty cursor starts at the first tool; an empty next cursor means
+// there are no more tools.
+//
+// Cursors are opaque, URL-safe and signed with the toolset's cursor key.
+// They record the last returned ID, so paging stays consistent across
+// concurrent Add and Remove: tools are never returned twice, and tools
+// added or removed after the cursor position are reflected in later pages.
+func (ts *Toolset) Page(cursor string, limit int) ([]*tooladapter.CanonicalTool, string, error) {
+	return ts.page(ts.Snapshot().tools, cursor, limit)
+}
+
+// SetCursorKey sets the key that signs page cursors. Servers running
+// several replicas of the same toolset should share a key so that cursors
+// remain valid across replicas. Without a key, cursors are signed with a
+// random key generated once per process, so they stay valid across views
+// and rebuilds of a toolset with the same name (ViewFor, LiveToolset).
+// Views created by ViewFor and Filter, and toolsets published by
+// LiveToolset, keep a key set here. Changing the key invalidates
+// outstanding cursors.
+func (ts *Toolset) SetCursorKey(key []byte) {
+	ts.cursorMu.Lock()
+	defer ts.cursorMu.Unlock()
+	ts.cursorKey = append([]byte(nil), key...)
+}
+
+// processCursorKey is the cursor key of toolsets without SetCursorKey.
+var processCursorKey = sync.OnceValue(func() []byte {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key) // never fails
+	return key
+})
+
+// page slices a sorted tool snapshot according to cursor and limit.
+// The returned page is a copy, so tools may be a shared snapshot slice.
+func (ts *Toolset) page(tools []*tooladapter.CanonicalTool, cursor string, limit int) ([]*tooladapter.CanonicalTool, string, error) {
+	if limit <= 0 {
+		return nil, "", fmt.Errorf("page limit %d must be positive", limit)
+	}
+	start := 0
+	if cursor != "" {
+		after, err := ts.decodeCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(tools), func(i int) bool { return tools[i].ID() > after })
+	}
+	end := min(start+limit, len(tools))
+	page := append(make([]*tooladapter.CanonicalTool, 0, end-start), tools[start:end]...)
+	if end == len(tools) {
+		return page, "", nil
+	}
+	return page, ts.encodeCursor(tools[end-1].ID()), nil
+}
+
+// encodeCursor returns the signed cursor for the position after id.
+func (ts *Toolset) encodeCursor(id string) string {
+	payload := append([]byte{cursorVersion}, id...)
+	return base64.RawURLEncoding.EncodeToString(append(payload, ts.cursorMAC(payload)...))
+}
+
+// decodeCursor verifies a cursor and returns the ID it points after.
+func (ts *Toolset) decodeCursor(cursor string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(data) < 1+cursorMACSize {
+		return "", ErrInvalidCursor
+	}
+	payload, mac := data[:len(data)-cursorMACSize], data[len(data)-cursorMACSize:]
+	if payload[0] != cursorVersion || !hmac.Equal(mac, ts.cursorMAC(payload)) {
+		return "", ErrInvalidCursor
+	}
+	return string(payload[1:]), nil
+}
+
+// cursorMAC signs a cursor payload, binding it to the toolset name.
+func (ts *Toolset) cursorMAC(payload []byte) []byte {
+	h := hmac.New(sha256.New, ts.getCursorKey())
+	h.Write([]byte(ts.name))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return h.Sum(nil)[:cursorMACSize]
+}
+
+func (ts *Toolset) getCursorKey() []byte {
+	ts.cursorMu.Lock()
+	defer ts.cursorMu.Unlock()
+	if ts.cursorKey == nil {
+		return processCursorKey()
+	}
+	return ts.cursorKey
+}
+
+// inheritCursorKey copies a key set with SetCursorKey from parent.
+func (ts *Toolset) inheritCursorKey(parent *Toolset) *Toolset {
+	parent.cursorMu.Lock()
+	key := parent.cursorKey
+	parent.cursorMu.Unlock()
+	if key != nil {
+		ts.SetCursorKey(key)
+	}
+	return ts
+}
+
+// ExportPage converts one page of tools, as returned by Toolset.Page, to
+// the adapter's format. It stops at the first conversion error.
+//
+// With a naming strategy, names and collisions are resolved against the
+// whole toolset so that a tool's exported name does not depend on paging.
+func (e *Exposure) ExportPage(cursor string, limit int) ([]any, string, error) {
+	if err := e.check(); err != nil {
+		return nil, "", err
+	}
+	tools := e.toolset.Tools()
+	var names *NameMap
+	var collisions map[string]error
+	if e.naming != nil {
+		names, collisions = assignNames(e.naming.Name, tools)
+	}
+	page, next, err := e.toolset.page(tools, cursor, limit)
+	if err != nil {
+		return nil, "", err
+	}
+	result := make([]any, 0, len(page))
+	for _, t := range page {
+		if err := collisions[t.ID()]; err != nil {
+			return nil, "", err
+		}
+		converted, err := e.convert(t, names)
+		if err != nil {
+			return nil, "", err
+		}
+		result = append(result, converted)
+	}
+	return result, next, nil
+}
@@ -0,0 +1,195 @@
+package toolset
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func pagedToolset(names ...string) *Toolset {
+	ts := New("paged")
+	for _, n := range names {
+		ts.Add(makeTool("ns", n, nil))
+	}
+	return ts
+}
+
+func pageIDs(tools []*tooladapter.CanonicalTool) []string {
+	ids := make([]string, len(tools))
+	for i, t := range tools {
+		ids[i] = t.ID()
+	}
+	return ids
+}
+
+func TestToolset_Page(t *testing.T) {
+	t.Run("walks all tools in ID order", func(t *testing.T) {
+		ts := pagedToolset("e", "a", "d", "b", "c")
+		var got [][]string
+		cursor := ""
+		for {
+			page, next, err := ts.Page(cursor, 2)
+			if err != nil {
+				t.Fatalf("Page() error = %v", err)
+			}
+			got = append(got, pageIDs(page))
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		want := [][]string{{"ns:a", "ns:b"}, {"ns:c", "ns:d"}, {"ns:e"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("pages = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("exact fit has no next cursor", func(t *testing.T) {
+		_, next, _ := pagedToolset("a", "b").Page("", 2)
+		if next != "" {
+			t.Errorf("next = %q, want empty", next)
+		}
+	})
+
+	t.Run("stable across concurrent mutation", func(t *testing.T) {
+		ts := pagedToolset("a", "b", "c", "d")
+		_, next, _ := ts.Page("", 2)
+		ts.Remove("ns:b")                 // before the cursor: no effect
+		ts.Add(makeTool("ns", "a0", nil)) // before the cursor: not revisited
+		ts.Remove("ns:c")                 // after the cursor: skipped
+		ts.Add(makeTool("ns", "bb", nil)) // after the cursor: included
+		page, _, err := ts.Page(next, 10)
+		if err != nil {
+			t.Fatalf("Page() error = %v", err)
+		}
+		if got := pageIDs(page); !reflect.DeepEqual(got, []string{"ns:bb", "ns:d"}) {
+			t.Errorf("page = %v, want [ns:bb ns:d]", got)
+		}
+	})
+
+	t.Run("rejects tampered and foreign cursors", func(t *testing.T) {
+		ts := pagedToolset("a", "b", "c")
+		_, next, _ := ts.Page("", 1)
+
+		data, _ := base64.RawURLEncoding.DecodeString(next)
+		data[1] ^= 1
+		tampered := base64.RawURLEncoding.EncodeToString(data)
+
+		other := pagedToolset("a", "b", "c")
+		other.SetCursorKey([]byte("other"))
+		renamed := New("renamed")
+		for _, t := range ts.Tools() {
+			renamed.Add(t)
+		}
+		for name, cursor := range map[string]string{
+			"tampered":  tampered,
+			"garbage":   "not a cursor!",
+			"truncated": next[:4],
+		} {
+			if _, _, err := ts.Page(cursor, 1); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+			}
+		}
+		if _, _, err := other.Page(next, 1); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("foreign key: err = %v, want ErrInvalidCursor", err)
+		}
+		if _, _, err := renamed.Page(next, 1); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("foreign name: err = %v, want ErrInvalidCursor", err)
+		}
+	})
+
+	t.Run("cursors survive per-request views", func(t *testing.T) {
+		for name, key := range map[string][]byte{"process key": nil, "explicit key": []byte("secret")} {
+			ts := pagedToolset("a", "b", "c")
+			if key != nil {
+				ts.SetCursorKey(key)
+			}
+			_, next, _ := ts.ViewFor(context.Background(), nil, AllowAll()).Page("", 1)
+			page, _, err := ts.ViewFor(context.Background(), nil, AllowAll()).Page(next, 1)
+			if err != nil || !reflect.DeepEqual(pageIDs(page), []string{"ns:b"}) {
+				t.Errorf("%s: Page() = %v, %v; want [ns:b]", name, pageIDs(page), err)
+			}
+		}
+	})
+
+	t.Run("cursors survive live rebuilds", func(t *testing.T) {
+		reg := &watchRegistry{}
+		reg.Set(makeTool("ns", "a", nil), makeTool("ns", "b", nil), makeTool("ns", "c", nil))
+		live, err := NewLiveToolset(NewBuilder("paged").FromRegistry(reg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer live.Close()
+		live.Toolset().SetCursorKey([]byte("secret"))
+
+		_, next, _ := live.Toolset().Page("", 1)
+		reg.Set(makeTool("ns", "a", nil), makeTool("ns", "c", nil), makeTool("ns", "d", nil))
+		page, _, err := live.Toolset().Page(next, 2)
+		if err != nil || !reflect.DeepEqual(pageIDs(page), []string{"ns:c", "ns:d"}) {
+			t.Errorf("Page() = %v, %v; want [ns:c ns:d]", pageIDs(page), err)
+		}
+	})
+
+	t.Run("shared key validates across instances", func(t *testing.T) {
+		a, b := pagedToolset("a", "b", "c"), pagedToolset("a", "b", "c")
+		a.SetCursorKey([]byte("secret"))
+		b.SetCursorKey([]byte("secret"))
+		_, next, _ := a.Page("", 1)
+		page, _, err := b.Page(next, 1)
+		if err != nil || !reflect.DeepEqual(pageIDs(page), []string{"ns:b"}) {
+			t.Errorf("Page() = %v, %v; want [ns:b]", pageIDs(page), err)
+		}
+	})
+
+	t.Run("limit must be positive", func(t *testing.T) {
+		if _, _, err := pagedToolset("a").Page("", 0); err == nil {
+			t.Error("Page() with limit 0 should fail")
+		}
+	})
+}
+
+func TestExposure_ExportPage(t *testing.T) {
+	t.Run("converts one page", func(t *testing.T) {
+		ts := pagedToolset("a", "b", "c")
+		exp := NewExposure(ts, &mockAdapter{name: "mock"})
+		items, next, err := exp.ExportPage("", 2)
+		if err != nil {
+			t.Fatalf("ExportPage() error = %v", err)
+		}
+		if len(items) != 2 || next == "" {
+			t.Fatalf("items = %d, next = %q", len(items), next)
+		}
+		items, next, err = exp.ExportPage(next, 2)
+		if err != nil || len(items) != 1 || next != "" {
+			t.Errorf("second page = %d items, next %q, err %v", len(items), next, err)
+		}
+		if m := items[0].(map[string]any); m["name"] != "c" {
+			t.Errorf("item = %v, want c", m)
+		}
+	})
+
+	t.Run("names are resolved against the whole toolset", func(t *testing.T) {
+		ts := New("test")
+		ts.Add(makeTool("web.v2", "fetch", nil))
+		ts.Add(makeTool("web_v2", "fetch", nil))
+		exp := NewExposure(ts, &mockAdapter{name: "mock"}).WithNaming(NamingStrategy{})
+		_, next, err := exp.ExportPage("", 1)
+		if err != nil {
+			t.Fatalf("first page error = %v", err)
+		}
+		var collision *NameCollisionError
+		if _, _, err := exp.ExportPage(next, 1); !errors.As(err, &collision) {
+			t.Errorf("second page error = %v, want NameCollisionError", err)
+		}
+	})
+
+	t.Run("nil adapter", func(t *testing.T) {
+		if _, _, err := NewExposure(pagedToolset("a"), nil).ExportPage("", 1); err == nil {
+			t.Error("ExportPage() should fail for nil adapter")
+		}
+	})
+}
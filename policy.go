@@ -1,6 +1,11 @@
 package toolset
 
-import "github.com/jonwraymond/tooladapter"
+import (
+	"context"
+	"strconv"
+
+	"github.com/jonwraymond/tooladapter"
+)
 
 // Policy decides whether a tool is allowed.
 //
@@ -10,6 +15,9 @@ import "github.com/jonwraymond/tooladapter"
 // - Ownership: implementations must not mutate the tool; treat it as read-only.
 // - Determinism: for a given tool, Allow returns a stable result.
 // - Nil handling: if tool is nil, Allow must return false.
+//
+// The built-in policies also implement DecisionPolicy; use Explain to obtain
+// decisions with reasons from any Policy.
 type Policy interface {
 	Allow(tool *tooladapter.CanonicalTool) bool
 }
@@ -27,15 +35,15 @@ func (f PolicyFunc) Allow(t *tooladapter.CanonicalTool) bool {
 
 // AllowAll returns a policy that allows all tools.
 func AllowAll() Policy {
-	return PolicyFunc(func(t *tooladapter.CanonicalTool) bool {
-		return t != nil
+	return DecisionFunc(func(t *tooladapter.CanonicalTool) Decision {
+		return Decision{Effect: EffectAllow, Rule: "allow-all", Reason: "all tools are allowed"}
 	})
 }
 
 // DenyAll returns a policy that denies all tools.
 func DenyAll() Policy {
-	return PolicyFunc(func(t *tooladapter.CanonicalTool) bool {
-		return false
+	return DecisionFunc(func(t *tooladapter.CanonicalTool) Decision {
+		return Decision{Effect: EffectDeny, Rule: "deny-all", Reason: "all tools are denied"}
 	})
 }
 
@@ -45,11 +53,15 @@ func AllowNamespaces(ns ...string) Policy {
 	for _, n := range ns {
 		set[n] = true
 	}
-	return PolicyFunc(func(t *tooladapter.CanonicalTool) bool {
-		if t == nil {
-			return false
+	return DecisionFunc(func(t *tooladapter.CanonicalTool) Decision {
+		d := Decision{Rule: "allow-namespaces", Attribute: "namespace", Value: t.Namespace}
+		if set[t.Namespace] {
+			d.Effect = EffectAllow
+			d.Reason = "namespace " + quoteValue(t.Namespace) + " is allowed"
+		} else {
+			d.Reason = "namespace " + quoteValue(t.Namespace) + " is not in the allowed set"
 		}
-		return set[t.Namespace]
+		return d
 	})
 }
 
@@ -59,35 +71,93 @@ func DenyTags(tags ...string) Policy {
 	for _, tag := range tags {
 		set[tag] = true
 	}
-	return PolicyFunc(func(t *tooladapter.CanonicalTool) bool {
-		if t == nil {
-			return false
-		}
+	return DecisionFunc(func(t *tooladapter.CanonicalTool) Decision {
 		for _, tag := range t.Tags {
 			if set[tag] {
-				return false
+				return Decision{
+					Effect:    EffectDeny,
+					Rule:      "deny-tags",
+					Reason:    "tag " + quoteValue(tag) + " is denied",
+					Attribute: "tags",
+					Value:     tag,
+				}
 			}
 		}
-		return true
+		return Decision{Effect: EffectAllow, Rule: "deny-tags", Reason: "no denied tags"}
 	})
 }
 
 // AllowScopes returns a policy allowing tools requiring only allowed scopes.
+//
+// The policy also implements ContextPolicy: evaluated for a caller, a scope
+// must be both in allowed and granted to the caller (see AllowGrantedScopes
+// to check caller grants alone).
 func AllowScopes(allowed ...string) Policy {
 	set := make(map[string]bool, len(allowed))
 	for _, scope := range allowed {
 		set[scope] = true
 	}
-	return PolicyFunc(func(t *tooladapter.CanonicalTool) bool {
-		if t == nil {
-			return false
+	return &scopePolicy{allowed: set}
+}
+
+// scopePolicy checks RequiredScopes against a static allow list, the
+// caller's grants, or both.
+type scopePolicy struct {
+	allowed map[string]bool // static allow list; unused when granted
+	granted bool            // check caller grants only
+}
+
+// Allow implements Policy.
+func (p *scopePolicy) Allow(t *tooladapter.CanonicalTool) bool {
+	return p.Decide(t).Allowed()
+}
+
+// Decide implements DecisionPolicy, evaluating for an anonymous caller when
+// the policy checks grants.
+func (p *scopePolicy) Decide(t *tooladapter.CanonicalTool) Decision {
+	return p.decide(nil, t, !p.granted)
+}
+
+// AllowContext implements ContextPolicy.
+func (p *scopePolicy) AllowContext(ctx context.Context, caller *Principal, t *tooladapter.CanonicalTool) bool {
+	return p.decideContext(ctx, caller, t).Allowed()
+}
+
+// decideContext explains AllowContext.
+func (p *scopePolicy) decideContext(_ context.Context, caller *Principal, t *tooladapter.CanonicalTool) Decision {
+	return p.decide(caller, t, false)
+}
+
+// decide checks each required scope against the allow list and, unless
+// static, the caller's grants.
+func (p *scopePolicy) decide(caller *Principal, t *tooladapter.CanonicalTool, static bool) Decision {
+	if t == nil {
+		return nilToolDecision
+	}
+	rule := "allow-scopes"
+	if p.granted {
+		rule = "allow-granted-scopes"
+	}
+	// Tools with no required scopes are allowed
+	for _, scope := range t.RequiredScopes {
+		reason := ""
+		switch {
+		case !p.granted && !p.allowed[scope]:
+			reason = "required scope " + quoteValue(scope) + " is not allowed"
+		case !static && !caller.HasScope(scope):
+			reason = "required scope " + quoteValue(scope) + " is not granted"
 		}
-		// Tools with no required scopes are allowed
-		for _, scope := range t.RequiredScopes {
-			if !set[scope] {
-				return false
-			}
+		if reason != "" {
+			return Decision{Effect: EffectDeny, Rule: rule, Reason: reason, Attribute: "scopes", Value: scope}
 		}
-		return true
-	})
+	}
+	return Decision{Effect: EffectAllow, Rule: rule, Reason: "all required scopes are allowed"}
+}
+
+// quoteValue quotes s for use in decision reasons.
+func quoteValue(s string) string {
+	if s == "" {
+		return "(empty)"
+	}
+	return strconv.Quote(s)
 }
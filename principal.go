@@ -0,0 +1,166 @@
+package toolset
+
+import (
+	"context"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// Principal identifies the caller a toolset is evaluated for.
+type Principal struct {
+	// Subject is the caller's identity, e.g. a user or service ID.
+	Subject string
+
+	// Roles are the caller's role names.
+	Roles []string
+
+	// Scopes are the scopes granted to the caller.
+	Scopes []string
+
+	// Tenant is the caller's tenant, if any. AllowTenants checks it.
+	Tenant string
+
+	// Attributes holds arbitrary caller attributes. No policy in this
+	// package reads them; they are passed through to custom ContextPolicy
+	// implementations.
+	Attributes map[string]string
+}
+
+// HasRole reports whether the principal has role. A nil principal has none.
+func (p *Principal) HasRole(role string) bool {
+	return p != nil && containsString(p.Roles, role)
+}
+
+// HasScope reports whether scope is granted. A nil principal has none.
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && containsString(p.Scopes, scope)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal stored by WithPrincipal, or nil.
+func PrincipalFrom(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// ContextPolicy decides whether a tool is allowed for a caller.
+//
+// Contract:
+// - Concurrency: implementations must be safe for concurrent use after construction.
+// - Errors: implementations must encode deny via false; no panic for invalid input.
+// - Ownership: implementations must not mutate the tool or principal; treat them as read-only.
+// - Determinism: for a given principal and tool, AllowContext returns a stable result.
+// - Nil handling: if tool is nil, AllowContext must return false; a nil principal is an anonymous caller with no roles or scopes.
+//
+// Policies in this package that implement ContextPolicy also implement
+// Policy, evaluating Allow as AllowContext for an anonymous caller.
+type ContextPolicy interface {
+	AllowContext(ctx context.Context, p *Principal, tool *tooladapter.CanonicalTool) bool
+}
+
+// ContextPolicyFunc adapts a function to the ContextPolicy and Policy interfaces.
+type ContextPolicyFunc func(ctx context.Context, p *Principal, tool *tooladapter.CanonicalTool) bool
+
+// AllowContext implements ContextPolicy.
+func (f ContextPolicyFunc) AllowContext(ctx context.Context, p *Principal, t *tooladapter.CanonicalTool) bool {
+	if t == nil || f == nil {
+		return false
+	}
+	return f(ctx, p, t)
+}
+
+// Allow implements Policy for an anonymous caller.
+func (f ContextPolicyFunc) Allow(t *tooladapter.CanonicalTool) bool {
+	return f.AllowContext(context.Background(), nil, t)
+}
+
+// LiftPolicy returns p as a ContextPolicy. Policies that already implement
+// ContextPolicy are returned unchanged; others ignore the caller. A nil
+// policy denies every tool.
+func LiftPolicy(p Policy) ContextPolicy {
+	if cp, ok := p.(ContextPolicy); ok {
+		return cp
+	}
+	return ContextPolicyFunc(func(_ context.Context, _ *Principal, t *tooladapter.CanonicalTool) bool {
+		return p != nil && p.Allow(t)
+	})
+}
+
+// AllowGrantedScopes returns a policy allowing tools whose RequiredScopes
+// are all granted to the caller. Evaluated without a caller (Allow), only
+// tools requiring no scopes are allowed.
+func AllowGrantedScopes() Policy {
+	return &scopePolicy{granted: true}
+}
+
+// AllowTenants returns a policy allowing tools only to callers whose
+// Tenant is one of tenants. Anonymous callers and callers without a tenant
+// are denied, so Allow (evaluated without a caller) denies every tool.
+// Scope it to a tenant's tools with Applies, e.g.
+// Applies(NamespaceFilter("acme"), AllowTenants("acme")).
+func AllowTenants(tenants ...string) Policy {
+	set := make(map[string]bool, len(tenants))
+	for _, tenant := range tenants {
+		set[tenant] = true
+	}
+	return &tenantPolicy{tenants: set}
+}
+
+// tenantPolicy checks the caller's tenant against an allow list.
+type tenantPolicy struct {
+	tenants map[string]bool
+}
+
+// Allow implements Policy for an anonymous caller.
+func (p *tenantPolicy) Allow(t *tooladapter.CanonicalTool) bool {
+	return p.Decide(t).Allowed()
+}
+
+// Decide implements DecisionPolicy for an anonymous caller.
+func (p *tenantPolicy) Decide(t *tooladapter.CanonicalTool) Decision {
+	return p.decideContext(context.Background(), nil, t)
+}
+
+// AllowContext implements ContextPolicy.
+func (p *tenantPolicy) AllowContext(ctx context.Context, caller *Principal, t *tooladapter.CanonicalTool) bool {
+	return p.decideContext(ctx, caller, t).Allowed()
+}
+
+// decideContext explains AllowContext.
+func (p *tenantPolicy) decideContext(_ context.Context, caller *Principal, t *tooladapter.CanonicalTool) Decision {
+	if t == nil {
+		return nilToolDecision
+	}
+	tenant := ""
+	if caller != nil {
+		tenant = caller.Tenant
+	}
+	if tenant == "" || !p.tenants[tenant] {
+		return Decision{Effect: EffectDeny, Rule: "allow-tenants", Reason: "tenant " + quoteValue(tenant) + " is not allowed", Value: tenant}
+	}
+	return Decision{Effect: EffectAllow, Rule: "allow-tenants", Reason: "tenant " + quoteValue(tenant) + " is allowed", Value: tenant}
+}
+
+// ViewFor returns the tools visible to a caller under policy. If p is nil,
+// the principal stored in ctx by WithPrincipal is used. Policies that
+// implement ContextPolicy see the caller; plain policies are applied as is.
+// The view is a new Toolset with the same name; ts is not modified.
+func (ts *Toolset) ViewFor(ctx context.Context, p *Principal, policy Policy) *Toolset {
+	if p == nil {
+		p = PrincipalFrom(ctx)
+	}
+	cp := LiftPolicy(policy)
+	var visible []*tooladapter.CanonicalTool
+	for _, t := range ts.Snapshot().tools {
+		if cp.AllowContext(ctx, p, t) {
+			visible = append(visible, t)
+		}
+	}
+	return newFromTools(ts.name, visible).inheritCursorKey(ts)
+}
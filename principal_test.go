@@ -0,0 +1,203 @@
+package toolset
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func scopedTool(name string, scopes ...string) *tooladapter.CanonicalTool {
+	tool := makeTool("gh", name, nil)
+	tool.RequiredScopes = scopes
+	return tool
+}
+
+func scopedToolset() *Toolset {
+	ts := New("scoped")
+	ts.Add(scopedTool("public"))
+	ts.Add(scopedTool("read", "repo:read"))
+	ts.Add(scopedTool("write", "repo:read", "repo:write"))
+	ts.Add(scopedTool("admin", "admin"))
+	return ts
+}
+
+func TestPrincipal(t *testing.T) {
+	p := &Principal{Subject: "alice", Roles: []string{"dev"}, Scopes: []string{"repo:read"}}
+	if !p.HasRole("dev") || p.HasRole("ops") {
+		t.Error("HasRole mismatch")
+	}
+	if !p.HasScope("repo:read") || p.HasScope("repo:write") {
+		t.Error("HasScope mismatch")
+	}
+	var anon *Principal
+	if anon.HasRole("dev") || anon.HasScope("repo:read") {
+		t.Error("nil principal should have no roles or scopes")
+	}
+
+	ctx := WithPrincipal(context.Background(), p)
+	if PrincipalFrom(ctx) != p {
+		t.Error("PrincipalFrom should return the stored principal")
+	}
+	if PrincipalFrom(context.Background()) != nil {
+		t.Error("PrincipalFrom without principal should be nil")
+	}
+}
+
+func TestAllowGrantedScopes(t *testing.T) {
+	ctx := context.Background()
+	policy := AllowGrantedScopes()
+	cp := policy.(ContextPolicy)
+	reader := &Principal{Scopes: []string{"repo:read"}}
+
+	if !cp.AllowContext(ctx, reader, scopedTool("read", "repo:read")) {
+		t.Error("granted scope should be allowed")
+	}
+	if cp.AllowContext(ctx, reader, scopedTool("write", "repo:read", "repo:write")) {
+		t.Error("ungranted scope should be denied")
+	}
+	if cp.AllowContext(ctx, reader, nil) {
+		t.Error("nil tool should be denied")
+	}
+	if !policy.Allow(scopedTool("public")) || policy.Allow(scopedTool("read", "repo:read")) {
+		t.Error("Allow without caller should only allow unscoped tools")
+	}
+	d := Explain(policy).Decide(scopedTool("read", "repo:read"))
+	if d.Rule != "allow-granted-scopes" || d.Value != "repo:read" {
+		t.Errorf("Decide() = %+v", d)
+	}
+}
+
+func TestAllowTenants(t *testing.T) {
+	ctx := context.Background()
+	acme := makeTool("acme", "deploy", nil)
+	shared := makeTool("shared", "search", nil)
+	policy := DenyOverrides(
+		Applies(NamespaceFilter("acme"), AllowTenants("acme")),
+		Applies(NamespaceFilter("shared"), AllowAll()),
+	)
+	cp := policy.(ContextPolicy)
+
+	tests := []struct {
+		name   string
+		caller *Principal
+		tool   *tooladapter.CanonicalTool
+		want   bool
+	}{
+		{"own tenant", &Principal{Tenant: "acme"}, acme, true},
+		{"other tenant", &Principal{Tenant: "globex"}, acme, false},
+		{"no tenant", &Principal{Subject: "alice"}, acme, false},
+		{"anonymous", nil, acme, false},
+		{"unscoped tool", &Principal{Tenant: "globex"}, shared, true},
+		{"nil tool", &Principal{Tenant: "acme"}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cp.AllowContext(ctx, tt.caller, tt.tool); got != tt.want {
+				t.Errorf("AllowContext() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if AllowTenants("acme").Allow(acme) {
+		t.Error("Allow without caller should deny")
+	}
+	view := func(tenant string) []string {
+		ts := New("tenants")
+		ts.Add(acme)
+		ts.Add(shared)
+		return ts.ViewFor(ctx, &Principal{Tenant: tenant}, policy).IDs()
+	}
+	if got := view("globex"); !reflect.DeepEqual(got, []string{"shared:search"}) {
+		t.Errorf("ViewFor(globex) = %v", got)
+	}
+	if got := view("acme"); !reflect.DeepEqual(got, []string{"acme:deploy", "shared:search"}) {
+		t.Errorf("ViewFor(acme) = %v", got)
+	}
+	d := Explain(AllowTenants("acme")).Decide(acme)
+	if d.Rule != "allow-tenants" || d.Reason != `tenant (empty) is not allowed` {
+		t.Errorf("Decide() = %+v", d)
+	}
+}
+
+func TestAllowScopes_Context(t *testing.T) {
+	ctx := context.Background()
+	cp, ok := AllowScopes("repo:read", "repo:write").(ContextPolicy)
+	if !ok {
+		t.Fatal("AllowScopes should implement ContextPolicy")
+	}
+	reader := &Principal{Scopes: []string{"repo:read", "admin"}}
+	if !cp.AllowContext(ctx, reader, scopedTool("read", "repo:read")) {
+		t.Error("allowed and granted scope should be allowed")
+	}
+	if cp.AllowContext(ctx, reader, scopedTool("write", "repo:write")) {
+		t.Error("allowed but ungranted scope should be denied")
+	}
+	if cp.AllowContext(ctx, reader, scopedTool("admin", "admin")) {
+		t.Error("granted but not allowed scope should be denied")
+	}
+}
+
+func TestLiftPolicy(t *testing.T) {
+	ctx := context.Background()
+	lifted := LiftPolicy(AllowNamespaces("gh"))
+	if !lifted.AllowContext(ctx, nil, scopedTool("x")) {
+		t.Error("lifted policy should ignore the caller")
+	}
+	if LiftPolicy(nil).AllowContext(ctx, nil, scopedTool("x")) {
+		t.Error("nil policy should deny")
+	}
+	scopes := AllowGrantedScopes()
+	if LiftPolicy(scopes) != scopes.(ContextPolicy) {
+		t.Error("ContextPolicy should be returned unchanged")
+	}
+	var nilFunc ContextPolicyFunc
+	if nilFunc.Allow(scopedTool("x")) {
+		t.Error("nil ContextPolicyFunc should deny")
+	}
+}
+
+func TestToolset_ViewFor(t *testing.T) {
+	ts := scopedToolset()
+
+	t.Run("projects per caller", func(t *testing.T) {
+		reader := &Principal{Subject: "r", Scopes: []string{"repo:read"}}
+		writer := &Principal{Subject: "w", Scopes: []string{"repo:read", "repo:write"}}
+		ctx := context.Background()
+
+		if got := ts.ViewFor(ctx, reader, AllowGrantedScopes()).IDs(); !reflect.DeepEqual(got, []string{"gh:public", "gh:read"}) {
+			t.Errorf("reader view = %v", got)
+		}
+		if got := ts.ViewFor(ctx, writer, AllowGrantedScopes()).IDs(); !reflect.DeepEqual(got, []string{"gh:public", "gh:read", "gh:write"}) {
+			t.Errorf("writer view = %v", got)
+		}
+		if ts.Count() != 4 {
+			t.Error("ViewFor should not modify the toolset")
+		}
+	})
+
+	t.Run("uses principal from context", func(t *testing.T) {
+		ctx := WithPrincipal(context.Background(), &Principal{Scopes: []string{"admin"}})
+		if got := ts.ViewFor(ctx, nil, AllowGrantedScopes()).IDs(); !reflect.DeepEqual(got, []string{"gh:admin", "gh:public"}) {
+			t.Errorf("view = %v", got)
+		}
+	})
+
+	t.Run("caller-aware function policy", func(t *testing.T) {
+		tenantOnly := ContextPolicyFunc(func(_ context.Context, p *Principal, tool *tooladapter.CanonicalTool) bool {
+			return p != nil && p.Attributes["plan"] == "pro" || tool.Name == "public"
+		})
+		free := ts.ViewFor(context.Background(), &Principal{Attributes: map[string]string{"plan": "free"}}, tenantOnly)
+		pro := ts.ViewFor(context.Background(), &Principal{Attributes: map[string]string{"plan": "pro"}}, tenantOnly)
+		if free.Count() != 1 || pro.Count() != 4 {
+			t.Errorf("free = %v, pro = %v", free.IDs(), pro.IDs())
+		}
+	})
+
+	t.Run("plain policy applies as is", func(t *testing.T) {
+		if got := ts.ViewFor(context.Background(), nil, DenyAll()).Count(); got != 0 {
+			t.Errorf("Count() = %d, want 0", got)
+		}
+	})
+}
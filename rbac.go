@@ -0,0 +1,393 @@
+package toolset
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+	"go.yaml.in/yaml/v3"
+)
+
+// RBACSpec is a declarative role-based access control document.
+//
+// Roles grant and deny tools by pattern and may inherit the grants and denies
+// of other roles. A caller is allowed a tool if any of its roles, or a role
+// they inherit, grants the tool and none denies it: explicit denies win.
+//
+// Use ParseRBAC or LoadRBAC to read a document with strict validation, and
+// NewRBACPolicy to compile it.
+type RBACSpec struct {
+	// Roles maps role names to their definitions.
+	Roles map[string]RoleSpec `json:"roles" yaml:"roles"`
+}
+
+// RoleSpec defines a role.
+type RoleSpec struct {
+	// Inherits lists roles whose grants and denies this role also has.
+	Inherits []string `json:"inherits,omitempty" yaml:"inherits,omitempty"`
+
+	// Grant selects the tools the role allows.
+	Grant *ToolPatterns `json:"grant,omitempty" yaml:"grant,omitempty"`
+
+	// Deny selects the tools the role denies, overriding any grant.
+	Deny *ToolPatterns `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// ToolPatterns selects tools by ID, namespace, tag or category glob (see
+// GlobIDs), e.g. "github:*" or "*:delete_*". A tool is selected if any
+// pattern matches.
+type ToolPatterns struct {
+	IDs        []string `json:"ids,omitempty" yaml:"ids,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+	Tags       []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+}
+
+// ParseRBAC decodes an RBACSpec from YAML or JSON and validates it.
+// Problems are reported together as ValidationErrors, as for ParseSpec.
+func ParseRBAC(data []byte) (*RBACSpec, error) {
+	root, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &nodeDecoder{}
+	s := &RBACSpec{}
+	d.mapping(root, "", map[string]func(*yaml.Node, string){
+		"roles": func(n *yaml.Node, p string) {
+			s.Roles = map[string]RoleSpec{}
+			d.entries(n, p, func(key, value *yaml.Node, p string) {
+				var r RoleSpec
+				d.mapping(value, p, map[string]func(*yaml.Node, string){
+					"inherits": func(n *yaml.Node, p string) { r.Inherits = d.strs(n, p) },
+					"grant":    func(n *yaml.Node, p string) { r.Grant = d.toolPatterns(n, p) },
+					"deny":     func(n *yaml.Node, p string) { r.Deny = d.toolPatterns(n, p) },
+				})
+				s.Roles[key.Value] = r
+			})
+		},
+	})
+	if err := d.errs.err(); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// toolPatterns decodes a ToolPatterns mapping.
+func (d *nodeDecoder) toolPatterns(n *yaml.Node, path string) *ToolPatterns {
+	tp := &ToolPatterns{}
+	d.mapping(n, path, map[string]func(*yaml.Node, string){
+		"ids":        func(n *yaml.Node, p string) { tp.IDs = d.strs(n, p) },
+		"namespaces": func(n *yaml.Node, p string) { tp.Namespaces = d.strs(n, p) },
+		"tags":       func(n *yaml.Node, p string) { tp.Tags = d.strs(n, p) },
+		"categories": func(n *yaml.Node, p string) { tp.Categories = d.strs(n, p) },
+	})
+	return tp
+}
+
+// LoadRBAC reads a YAML or JSON RBACSpec from r and validates it.
+func LoadRBAC(r io.Reader) (*RBACSpec, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRBAC(data)
+}
+
+// Validate checks the document for empty role names, invalid patterns,
+// unknown inherited roles and inheritance cycles.
+// It returns ValidationErrors listing every problem found.
+func (s *RBACSpec) Validate() error {
+	var errs ValidationErrors
+	if len(s.Roles) == 0 {
+		errs = append(errs, &FieldError{Path: "roles", Msg: "is required"})
+	}
+	names := s.roleNames()
+	for _, name := range names {
+		path := fieldPath("roles", name)
+		if name == "" {
+			errs = append(errs, &FieldError{Path: path, Msg: "role name must not be empty"})
+		}
+		r := s.Roles[name]
+		checkStrings(&errs, fieldPath(path, "inherits"), r.Inherits)
+		for i, parent := range r.Inherits {
+			if _, ok := s.Roles[parent]; !ok && parent != "" {
+				errs = append(errs, &FieldError{Path: indexPath(fieldPath(path, "inherits"), i), Msg: fmt.Sprintf("unknown role %q", parent)})
+			}
+		}
+		r.Grant.check(&errs, fieldPath(path, "grant"))
+		r.Deny.check(&errs, fieldPath(path, "deny"))
+	}
+	errs = append(errs, s.cycles(names)...)
+	return errs.err()
+}
+
+// check validates each pattern list.
+func (tp *ToolPatterns) check(errs *ValidationErrors, path string) {
+	if tp == nil {
+		return
+	}
+	for _, f := range tp.fields() {
+		listPath := fieldPath(path, f.field)
+		checkStrings(errs, listPath, f.patterns)
+		for i, pattern := range f.patterns {
+			if _, err := compileGlobs([]string{pattern}); err != nil {
+				*errs = append(*errs, &FieldError{Path: indexPath(listPath, i), Msg: err.Error()})
+			}
+		}
+	}
+}
+
+// patternField is one pattern list of a ToolPatterns.
+type patternField struct {
+	field     string // document field name
+	attribute string // decision attribute
+	get       func(*tooladapter.CanonicalTool) []string
+	patterns  []string
+}
+
+// fields lists the pattern lists in evaluation order.
+func (tp *ToolPatterns) fields() []patternField {
+	return []patternField{
+		{"ids", "id", toolID, tp.IDs},
+		{"namespaces", "namespace", toolNamespace, tp.Namespaces},
+		{"tags", "tags", toolTags, tp.Tags},
+		{"categories", "category", toolCategory, tp.Categories},
+	}
+}
+
+// roleNames returns the role names sorted.
+func (s *RBACSpec) roleNames() []string {
+	names := make([]string, 0, len(s.Roles))
+	for name := range s.Roles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cycles reports each inheritance cycle once, at the role where it was
+// first entered in name order.
+func (s *RBACSpec) cycles(names []string) ValidationErrors {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	var errs ValidationErrors
+	state := make(map[string]int, len(names))
+	var stack []string
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+		for _, parent := range s.Roles[name].Inherits {
+			if _, ok := s.Roles[parent]; !ok {
+				continue
+			}
+			switch state[parent] {
+			case unvisited:
+				visit(parent)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != parent {
+					start--
+				}
+				cycle := append(append([]string(nil), stack[start:]...), parent)
+				errs = append(errs, &FieldError{
+					Path: fieldPath(fieldPath("roles", parent), "inherits"),
+					Msg:  "inheritance cycle " + strings.Join(cycle, " -> "),
+				})
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[name] = done
+	}
+	for _, name := range names {
+		if state[name] == unvisited {
+			visit(name)
+		}
+	}
+	return errs
+}
+
+// RBACPolicy evaluates tools against the roles of a caller.
+//
+// It implements ContextPolicy using Principal.Roles. As a plain Policy it
+// evaluates for an anonymous caller, which has no roles and is denied every
+// tool; use ForRoles for a policy bound to fixed roles. Roles unknown to the
+// policy are ignored. RBACPolicy is immutable and safe for concurrent use.
+type RBACPolicy struct {
+	roles map[string]*rbacRole
+}
+
+// rbacRole is a compiled role.
+type rbacRole struct {
+	name      string
+	inherited []string // the role and every role it inherits, sorted
+	grant     toolMatcher
+	deny      toolMatcher
+}
+
+// NewRBACPolicy validates spec and compiles it into a policy.
+func NewRBACPolicy(spec RBACSpec) (*RBACPolicy, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	p := &RBACPolicy{roles: make(map[string]*rbacRole, len(spec.Roles))}
+	for name, r := range spec.Roles {
+		p.roles[name] = &rbacRole{
+			name:  name,
+			grant: compileToolPatterns(r.Grant),
+			deny:  compileToolPatterns(r.Deny),
+		}
+	}
+	for name := range spec.Roles {
+		seen := map[string]bool{}
+		var walk func(string)
+		walk = func(n string) {
+			if seen[n] {
+				return
+			}
+			seen[n] = true
+			for _, parent := range spec.Roles[n].Inherits {
+				walk(parent)
+			}
+		}
+		walk(name)
+		inherited := make([]string, 0, len(seen))
+		for n := range seen {
+			inherited = append(inherited, n)
+		}
+		sort.Strings(inherited)
+		p.roles[name].inherited = inherited
+	}
+	return p, nil
+}
+
+// ForRoles returns a policy that evaluates tools for a caller with roles.
+func (p *RBACPolicy) ForRoles(roles ...string) Policy {
+	effective := p.effective(roles)
+	return DecisionFunc(func(t *tooladapter.CanonicalTool) Decision {
+		return p.decide(effective, t)
+	})
+}
+
+// Allow implements Policy for an anonymous caller.
+func (p *RBACPolicy) Allow(t *tooladapter.CanonicalTool) bool {
+	return p.Decide(t).Allowed()
+}
+
+// Decide implements DecisionPolicy for an anonymous caller.
+func (p *RBACPolicy) Decide(t *tooladapter.CanonicalTool) Decision {
+	return p.decide(nil, t)
+}
+
+// AllowContext implements ContextPolicy.
+func (p *RBACPolicy) AllowContext(ctx context.Context, caller *Principal, t *tooladapter.CanonicalTool) bool {
+	return p.decideContext(ctx, caller, t).Allowed()
+}
+
+// decideContext explains AllowContext.
+func (p *RBACPolicy) decideContext(_ context.Context, caller *Principal, t *tooladapter.CanonicalTool) Decision {
+	var roles []string
+	if caller != nil {
+		roles = caller.Roles
+	}
+	return p.decide(p.effective(roles), t)
+}
+
+// effective returns the known roles among roles together with the roles
+// they inherit, sorted by name.
+func (p *RBACPolicy) effective(roles []string) []*rbacRole {
+	var names []string
+	for _, name := range roles {
+		if r, ok := p.roles[name]; ok {
+			names = append(names, r.inherited...)
+		}
+	}
+	names = sortedSet(names)
+	out := make([]*rbacRole, len(names))
+	for i, name := range names {
+		out[i] = p.roles[name]
+	}
+	return out
+}
+
+// decide applies denies before grants, in role name order.
+func (p *RBACPolicy) decide(roles []*rbacRole, t *tooladapter.CanonicalTool) Decision {
+	if t == nil {
+		return nilToolDecision
+	}
+	if len(roles) == 0 {
+		return Decision{Effect: EffectDeny, Rule: "rbac", Reason: "caller has no known roles", Attribute: "roles"}
+	}
+	for _, r := range roles {
+		if attr, value, ok := r.deny.match(t); ok {
+			return Decision{
+				Effect:    EffectDeny,
+				Rule:      "rbac",
+				Reason:    "role " + quoteValue(r.name) + " denies " + attr + " " + quoteValue(value),
+				Attribute: attr,
+				Value:     value,
+			}
+		}
+	}
+	for _, r := range roles {
+		if attr, value, ok := r.grant.match(t); ok {
+			return Decision{
+				Effect:    EffectAllow,
+				Rule:      "rbac",
+				Reason:    "role " + quoteValue(r.name) + " grants " + attr + " " + quoteValue(value),
+				Attribute: attr,
+				Value:     value,
+			}
+		}
+	}
+	return Decision{Effect: EffectDeny, Rule: "rbac", Reason: "no role grants the tool", Attribute: "roles"}
+}
+
+// toolMatcher is a compiled ToolPatterns.
+type toolMatcher []attributePatterns
+
+// attributePatterns matches one tool attribute against compiled globs.
+type attributePatterns struct {
+	attribute string
+	get       func(*tooladapter.CanonicalTool) []string
+	patterns  *patternSet
+}
+
+// compileToolPatterns compiles validated patterns; a nil tp matches nothing.
+func compileToolPatterns(tp *ToolPatterns) toolMatcher {
+	if tp == nil {
+		return nil
+	}
+	var m toolMatcher
+	for _, f := range tp.fields() {
+		if len(f.patterns) == 0 {
+			continue
+		}
+		set, err := compileGlobs(f.patterns)
+		if err != nil {
+			continue // unreachable: patterns are validated first
+		}
+		m = append(m, attributePatterns{attribute: f.attribute, get: f.get, patterns: set})
+	}
+	return m
+}
+
+// match returns the first matching attribute and value.
+func (m toolMatcher) match(t *tooladapter.CanonicalTool) (attribute, value string, ok bool) {
+	for _, ap := range m {
+		if v, ok := ap.patterns.matchAny(ap.get(t)); ok {
+			return ap.attribute, v, true
+		}
+	}
+	return "", "", false
+}
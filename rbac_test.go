@@ -0,0 +1,182 @@
+package toolset
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+const rbacDoc = `
+roles:
+  analyst:
+    grant:
+      namespaces: [search]
+      tags: [read]
+  operator:
+    inherits: [analyst]
+    grant:
+      ids: ["github:create_issue"]
+  admin:
+    inherits: [operator]
+    grant:
+      namespaces: ["*"]
+  intern:
+    inherits: [analyst]
+    deny:
+      tags: [sensitive]
+`
+
+func rbacTools() []*tooladapter.CanonicalTool {
+	return []*tooladapter.CanonicalTool{
+		makeTool("search", "web", nil),
+		makeTool("search", "payroll", []string{"sensitive"}),
+		makeTool("github", "list_issues", []string{"read"}),
+		makeTool("github", "create_issue", []string{"write"}),
+		makeTool("github", "delete_repo", []string{"write"}),
+	}
+}
+
+func mustRBAC(t *testing.T, doc string) *RBACPolicy {
+	t.Helper()
+	spec, err := ParseRBAC([]byte(doc))
+	if err != nil {
+		t.Fatalf("ParseRBAC() error = %v", err)
+	}
+	p, err := NewRBACPolicy(*spec)
+	if err != nil {
+		t.Fatalf("NewRBACPolicy() error = %v", err)
+	}
+	return p
+}
+
+func allowedIDs(p Policy, tools []*tooladapter.CanonicalTool) []string {
+	var ids []string
+	for _, tool := range tools {
+		if p.Allow(tool) {
+			ids = append(ids, tool.ID())
+		}
+	}
+	return ids
+}
+
+func TestRBACPolicy(t *testing.T) {
+	p := mustRBAC(t, rbacDoc)
+	tools := rbacTools()
+
+	tests := []struct {
+		roles []string
+		want  []string
+	}{
+		{[]string{"analyst"}, []string{"search:web", "search:payroll", "github:list_issues"}},
+		{[]string{"operator"}, []string{"search:web", "search:payroll", "github:list_issues", "github:create_issue"}},
+		{[]string{"admin"}, []string{"search:web", "search:payroll", "github:list_issues", "github:create_issue", "github:delete_repo"}},
+		{[]string{"intern"}, []string{"search:web", "github:list_issues"}},
+		{[]string{"intern", "admin"}, []string{"search:web", "github:list_issues", "github:create_issue", "github:delete_repo"}},
+		{[]string{"unknown"}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.roles, "+"), func(t *testing.T) {
+			if got := allowedIDs(p.ForRoles(tt.roles...), tools); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ForRoles() allows %v, want %v", got, tt.want)
+			}
+			caller := &Principal{Roles: tt.roles}
+			var got []string
+			for _, tool := range tools {
+				if p.AllowContext(context.Background(), caller, tool) {
+					got = append(got, tool.ID())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("AllowContext() allows %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("decisions name the role", func(t *testing.T) {
+		d := Explain(p.ForRoles("intern")).Decide(tools[1])
+		if d.Allowed() || d.Reason != `role "intern" denies tags "sensitive"` || d.Attribute != "tags" {
+			t.Errorf("Decide() = %+v", d)
+		}
+		d = Explain(p.ForRoles("operator")).Decide(tools[3])
+		if !d.Allowed() || d.Reason != `role "operator" grants id "github:create_issue"` {
+			t.Errorf("Decide() = %+v", d)
+		}
+	})
+
+	t.Run("glob patterns", func(t *testing.T) {
+		p := mustRBAC(t, `roles: {triage: {grant: {ids: ["*:*_issue*"]}, deny: {ids: ["github:create_*"]}}}`)
+		if got := allowedIDs(p.ForRoles("triage"), tools); !reflect.DeepEqual(got, []string{"github:list_issues"}) {
+			t.Errorf("ForRoles() allows %v", got)
+		}
+	})
+
+	t.Run("anonymous and nil", func(t *testing.T) {
+		if p.Allow(tools[0]) || p.AllowContext(context.Background(), nil, tools[0]) {
+			t.Error("anonymous caller should be denied")
+		}
+		if p.ForRoles("admin").Allow(nil) {
+			t.Error("Allow(nil) = true, want false")
+		}
+	})
+
+	t.Run("combines with ViewFor", func(t *testing.T) {
+		ts := New("all")
+		for _, tool := range tools {
+			ts.Add(tool)
+		}
+		view := ts.ViewFor(WithPrincipal(context.Background(), &Principal{Roles: []string{"analyst"}}), nil, p)
+		if view.Count() != 3 {
+			t.Errorf("ViewFor() = %v, want 3 tools", view.IDs())
+		}
+	})
+}
+
+func TestNewRBACPolicy_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"unknown role", "roles: {a: {inherits: [b]}}", []string{`roles.a.inherits[0]: unknown role "b"`}},
+		{"self cycle", "roles: {a: {inherits: [a]}}", []string{"roles.a.inherits: inheritance cycle a -> a"}},
+		{"cycle", "roles: {a: {inherits: [b]}, b: {inherits: [c]}, c: {inherits: [a]}}", []string{"roles.a.inherits: inheritance cycle a -> b -> c -> a"}},
+		{"no roles", "roles: {}", []string{"roles: is required"}},
+		{"invalid glob", "roles: {a: {grant: {ids: ['[a']}}}", []string{`roles.a.grant.ids[0]: invalid glob "[a": missing ]`}},
+		{"unknown field", "roles: {a: {grant: {names: [x]}}}", []string{"roles.a.grant.names (line 1): unknown field"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRBAC([]byte(tt.doc))
+			var verrs ValidationErrors
+			if !errors.As(err, &verrs) {
+				t.Fatalf("err = %v, want ValidationErrors", err)
+			}
+			var got []string
+			for _, e := range verrs {
+				got = append(got, e.Error())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("NewRBACPolicy validates", func(t *testing.T) {
+		spec := RBACSpec{Roles: map[string]RoleSpec{"a": {Inherits: []string{"a"}}}}
+		if _, err := NewRBACPolicy(spec); err == nil {
+			t.Error("NewRBACPolicy() should reject cycles")
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		spec, err := ParseRBAC([]byte(`{"roles": {"ops": {"grant": {"categories": ["ops"]}}}}`))
+		if err != nil || spec.Roles["ops"].Grant.Categories[0] != "ops" {
+			t.Errorf("ParseRBAC() = %+v, %v", spec, err)
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package toolset
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// StageKind identifies a build pipeline stage.
+type StageKind string
+
+const (
+	// StageFilter is a filter added to the Builder.
+	StageFilter StageKind = "filter"
+	// StagePolicy is the Builder's policy, applied after all filters.
+	StagePolicy StageKind = "policy"
+)
+
+// BuildReport explains how Builder.BuildWithReport arrived at a Toolset.
+// All ID slices are sorted lexicographically and de-duplicated.
+type BuildReport struct {
+	// Source lists the IDs of all non-nil source tools after duplicate IDs
+	// across sources were resolved.
+	Source []string
+
+	// Sources describes each source in the order added.
+	Sources []SourceReport
+
+	// Stages lists each stage in application order.
+	Stages []StageReport
+
+	// Tools traces every source tool, sorted by ID.
+	Tools []ToolTrace
+}
+
+// SourceReport describes a single Builder source.
+type SourceReport struct {
+	// Label is the source label ("tools" or "registry" for FromTools and
+	// FromRegistry).
+	Label string
+
+	// Priority is the source priority.
+	Priority int
+
+	// Provided lists the IDs the source offered.
+	Provided []string
+
+	// Won lists the IDs taken from this source.
+	Won []string
+}
+
+// StageReport describes the effect of a single pipeline stage.
+type StageReport struct {
+	// Kind is the stage kind.
+	Kind StageKind
+
+	// Index is the filter's position in the order added (0 for the policy).
+	Index int
+
+	// Label describes the stage, e.g. "namespace(github)" or "policy".
+	Label string
+
+	// Kept lists tools that survived the stage.
+	Kept []string
+
+	// Dropped lists tools the stage removed.
+	Dropped []string
+}
+
+// ToolTrace records the outcome for a single source tool.
+type ToolTrace struct {
+	// ID is the tool's ID.
+	ID string
+
+	// Included reports whether the tool is in the built Toolset.
+	Included bool
+
+	// Source is the label of the source the tool was taken from.
+	Source string
+
+	// Shadowed lists the labels of other sources that offered the same ID
+	// and lost on precedence, in the order added.
+	Shadowed []string
+
+	// Stage is the stage that excluded the tool (empty when included).
+	Stage StageKind
+
+	// FilterIndex is the index of the excluding filter (StageFilter only).
+	FilterIndex int
+
+	// Label is the label of the excluding stage.
+	Label string
+
+	// Decision is the policy decision that excluded the tool (StagePolicy only).
+	Decision *Decision
+}
+
+// String describes the trace in one line.
+func (t ToolTrace) String() string {
+	switch {
+	case t.Included:
+		return t.ID + ": included"
+	case t.Stage == StagePolicy && t.Decision != nil:
+		return t.ID + ": excluded by policy: " + t.Decision.String()
+	case t.Stage == StageFilter:
+		return fmt.Sprintf("%s: excluded by filter[%d] %s", t.ID, t.FilterIndex, t.Label)
+	default:
+		return t.ID + ": excluded by " + t.Label
+	}
+}
+
+// Trace returns the trace for a tool ID.
+func (r *BuildReport) Trace(id string) (ToolTrace, bool) {
+	i := sort.Search(len(r.Tools), func(i int) bool { return r.Tools[i].ID >= id })
+	if i < len(r.Tools) && r.Tools[i].ID == id {
+		return r.Tools[i], true
+	}
+	return ToolTrace{}, false
+}
+
+// Excluded returns the traces of all excluded tools, sorted by ID.
+func (r *BuildReport) Excluded() []ToolTrace {
+	var out []ToolTrace
+	for _, t := range r.Tools {
+		if !t.Included {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// String renders the report as human-readable text.
+func (r *BuildReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "source: %d tools\n", len(r.Source))
+	if len(r.Sources) > 1 {
+		for _, src := range r.Sources {
+			fmt.Fprintf(&sb, "  %s (priority %d): provided %d, won %d\n", src.Label, src.Priority, len(src.Provided), len(src.Won))
+		}
+	}
+	for _, st := range r.Stages {
+		name := string(st.Kind)
+		if st.Kind == StageFilter {
+			name = fmt.Sprintf("filter[%d] %s", st.Index, st.Label)
+		}
+		fmt.Fprintf(&sb, "%s: kept %d, dropped %d\n", name, len(st.Kept), len(st.Dropped))
+	}
+	for _, t := range r.Excluded() {
+		sb.WriteString("  ")
+		sb.WriteString(t.String())
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// addSources records the sources and the tools gathered from them.
+func (r *BuildReport) addSources(sources []builderSource, tools []*tooladapter.CanonicalTool, origins map[string]*toolOrigin) {
+	r.Source = toolIDs(tools)
+	r.Sources = make([]SourceReport, len(sources))
+	for i, src := range sources {
+		r.Sources[i] = SourceReport{Label: src.label, Priority: src.priority, Provided: []string{}, Won: []string{}}
+	}
+	r.Tools = make([]ToolTrace, len(r.Source))
+	for i, id := range r.Source {
+		origin := origins[id]
+		tr := ToolTrace{ID: id, Source: sources[origin.source].label}
+		r.Sources[origin.source].Provided = append(r.Sources[origin.source].Provided, id)
+		r.Sources[origin.source].Won = append(r.Sources[origin.source].Won, id)
+		for _, s := range sortedIndexes(origin.shadowed) {
+			tr.Shadowed = append(tr.Shadowed, sources[s].label)
+			r.Sources[s].Provided = append(r.Sources[s].Provided, id)
+		}
+		r.Tools[i] = tr
+	}
+	for i := range r.Sources {
+		sort.Strings(r.Sources[i].Provided)
+	}
+}
+
+// addFilterStage records a filter stage.
+func (r *BuildReport) addFilterStage(index int, label string, kept, dropped []*tooladapter.CanonicalTool) {
+	r.Stages = append(r.Stages, StageReport{
+		Kind:    StageFilter,
+		Index:   index,
+		Label:   label,
+		Kept:    toolIDs(kept),
+		Dropped: toolIDs(dropped),
+	})
+	for _, t := range dropped {
+		if tr := r.trace(t); tr != nil {
+			tr.Stage = StageFilter
+			tr.FilterIndex = index
+			tr.Label = label
+			tr.Decision = nil
+		}
+	}
+}
+
+// addPolicyStage records the policy stage. decisions[i] explains denied[i].
+func (r *BuildReport) addPolicyStage(allowed, denied []*tooladapter.CanonicalTool, decisions []Decision) {
+	r.Stages = append(r.Stages, StageReport{
+		Kind:    StagePolicy,
+		Label:   string(StagePolicy),
+		Kept:    toolIDs(allowed),
+		Dropped: toolIDs(denied),
+	})
+	for i, t := range denied {
+		if tr := r.trace(t); tr != nil {
+			d := decisions[i]
+			tr.Stage = StagePolicy
+			tr.FilterIndex = 0
+			tr.Label = string(StagePolicy)
+			tr.Decision = &d
+		}
+	}
+}
+
+// finish marks tools that survived every stage as included.
+func (r *BuildReport) finish() {
+	survivors := r.Source
+	if n := len(r.Stages); n > 0 {
+		survivors = r.Stages[n-1].Kept
+	}
+	kept := make(map[string]bool, len(survivors))
+	for _, id := range survivors {
+		kept[id] = true
+	}
+	for i := range r.Tools {
+		if kept[r.Tools[i].ID] {
+			tr := &r.Tools[i]
+			*tr = ToolTrace{ID: tr.ID, Included: true, Source: tr.Source, Shadowed: tr.Shadowed}
+		}
+	}
+}
+
+// sortedIndexes returns the sorted, de-duplicated indexes.
+func sortedIndexes(idx []int) []int {
+	out := append([]int(nil), idx...)
+	sort.Ints(out)
+	n := 0
+	for i, v := range out {
+		if i == 0 || v != out[n-1] {
+			out[n] = v
+			n++
+		}
+	}
+	return out[:n]
+}
+
+// trace returns the mutable trace for t, or nil for nil tools.
+func (r *BuildReport) trace(t *tooladapter.CanonicalTool) *ToolTrace {
+	if t == nil {
+		return nil
+	}
+	id := t.ID()
+	i := sort.Search(len(r.Tools), func(i int) bool { return r.Tools[i].ID >= id })
+	if i < len(r.Tools) && r.Tools[i].ID == id {
+		return &r.Tools[i]
+	}
+	return nil
+}
+
+// toolIDs returns the sorted, de-duplicated IDs of the non-nil tools.
+func toolIDs(tools []*tooladapter.CanonicalTool) []string {
+	seen := make(map[string]bool, len(tools))
+	ids := make([]string, 0, len(tools))
+	for _, t := range tools {
+		if t == nil || seen[t.ID()] {
+			continue
+		}
+		seen[t.ID()] = true
+		ids = append(ids, t.ID())
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,156 @@
+package toolset
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func journeyTools() []*tooladapter.CanonicalTool {
+	return []*tooladapter.CanonicalTool{
+		makeTool("mcp", "search", []string{"read", "safe"}),
+		makeTool("mcp", "execute", []string{"write", "danger"}),
+		makeTool("mcp", "fetch", []string{"read", "safe"}),
+		makeTool("slack", "send", []string{"safe"}),
+	}
+}
+
+func TestBuilder_BuildWithReport(t *testing.T) {
+	t.Run("traces filter and policy exclusions", func(t *testing.T) {
+		ts, report, err := NewBuilder("mcp-safe").
+			FromTools(journeyTools()).
+			WithNamespace("mcp").
+			WithTags([]string{"safe"}).
+			WithPolicy(DenyTags("danger")).
+			ExcludeTools([]string{"mcp:fetch"}).
+			BuildWithReport()
+		if err != nil {
+			t.Fatalf("BuildWithReport() error = %v", err)
+		}
+		if !reflect.DeepEqual(ts.IDs(), []string{"mcp:search"}) {
+			t.Fatalf("IDs() = %v, want [mcp:search]", ts.IDs())
+		}
+
+		if len(report.Stages) != 4 {
+			t.Fatalf("len(Stages) = %d, want 4", len(report.Stages))
+		}
+		if got := report.Stages[0]; got.Label != "namespace(mcp)" || !reflect.DeepEqual(got.Dropped, []string{"slack:send"}) {
+			t.Errorf("stage 0 = %+v", got)
+		}
+		if got := report.Stages[1]; !reflect.DeepEqual(got.Kept, []string{"mcp:fetch", "mcp:search"}) {
+			t.Errorf("stage 1 kept = %v", got.Kept)
+		}
+
+		tr, ok := report.Trace("slack:send")
+		if !ok || tr.Included || tr.Stage != StageFilter || tr.FilterIndex != 0 {
+			t.Errorf("Trace(slack:send) = %+v", tr)
+		}
+		tr, _ = report.Trace("mcp:execute")
+		if tr.Stage != StageFilter || tr.FilterIndex != 1 || tr.Label != "tags-all(safe)" {
+			t.Errorf("Trace(mcp:execute) = %+v", tr)
+		}
+		tr, _ = report.Trace("mcp:fetch")
+		if tr.FilterIndex != 2 || tr.Label != "deny-ids(mcp:fetch)" {
+			t.Errorf("Trace(mcp:fetch) = %+v", tr)
+		}
+		tr, _ = report.Trace("mcp:search")
+		if !tr.Included {
+			t.Errorf("Trace(mcp:search) = %+v, want included", tr)
+		}
+	})
+
+	t.Run("policy decision recorded", func(t *testing.T) {
+		_, report, err := NewBuilder("test").
+			FromTools(journeyTools()).
+			WithNamespace("mcp").
+			WithPolicy(DenyTags("danger")).
+			BuildWithReport()
+		if err != nil {
+			t.Fatalf("BuildWithReport() error = %v", err)
+		}
+		tr, _ := report.Trace("mcp:execute")
+		if tr.Stage != StagePolicy || tr.Decision == nil {
+			t.Fatalf("Trace(mcp:execute) = %+v, want policy exclusion", tr)
+		}
+		if tr.Decision.Rule != "deny-tags" || tr.Decision.Value != "danger" {
+			t.Errorf("Decision = %+v", tr.Decision)
+		}
+		if got := len(report.Excluded()); got != 2 {
+			t.Errorf("len(Excluded()) = %d, want 2", got)
+		}
+	})
+
+	t.Run("matches Build", func(t *testing.T) {
+		b := NewBuilder("test").
+			FromTools(journeyTools()).
+			WithTags([]string{"read"}).
+			WithPolicy(AllowNamespaces("mcp"))
+		plain, _ := b.Build()
+		reported, _, _ := b.BuildWithReport()
+		if !reflect.DeepEqual(plain.IDs(), reported.IDs()) {
+			t.Errorf("Build() = %v, BuildWithReport() = %v", plain.IDs(), reported.IDs())
+		}
+	})
+
+	t.Run("no source returns error", func(t *testing.T) {
+		_, report, err := NewBuilder("test").BuildWithReport()
+		if err == nil || report != nil {
+			t.Errorf("BuildWithReport() = %v, %v; want nil report and error", report, err)
+		}
+	})
+
+	t.Run("nil source tools ignored", func(t *testing.T) {
+		tools := []*tooladapter.CanonicalTool{nil, makeTool("ns", "a", nil)}
+		_, report, err := NewBuilder("test").FromTools(tools).WithFilter(Always()).BuildWithReport()
+		if err != nil {
+			t.Fatalf("BuildWithReport() error = %v", err)
+		}
+		if !reflect.DeepEqual(report.Source, []string{"ns:a"}) {
+			t.Errorf("Source = %v", report.Source)
+		}
+	})
+
+	t.Run("String lists exclusions", func(t *testing.T) {
+		_, report, _ := NewBuilder("test").
+			FromTools(journeyTools()).
+			WithNamespace("mcp").
+			BuildWithReport()
+		out := report.String()
+		if !strings.Contains(out, "filter[0] namespace(mcp): kept 3, dropped 1") {
+			t.Errorf("String() missing stage summary:\n%s", out)
+		}
+		if !strings.Contains(out, "slack:send: excluded by filter[0] namespace(mcp)") {
+			t.Errorf("String() missing exclusion:\n%s", out)
+		}
+	})
+	t.Run("records winning and shadowed sources", func(t *testing.T) {
+		_, report, err := NewBuilder("test").
+			AddTools("a", 0, []*tooladapter.CanonicalTool{makeTool("ns", "x", nil), makeTool("ns", "y", nil)}).
+			AddTools("b", 0, []*tooladapter.CanonicalTool{makeTool("ns", "x", nil)}).
+			AddTools("c", 5, []*tooladapter.CanonicalTool{makeTool("ns", "x", nil)}).
+			BuildWithReport()
+		if err != nil {
+			t.Fatalf("BuildWithReport() error = %v", err)
+		}
+		tr, _ := report.Trace("ns:x")
+		if !tr.Included || tr.Source != "c" || !reflect.DeepEqual(tr.Shadowed, []string{"a", "b"}) {
+			t.Errorf("Trace(ns:x) = %+v, want included from c shadowing [a b]", tr)
+		}
+		if tr, _ := report.Trace("ns:y"); tr.Source != "a" || tr.Shadowed != nil {
+			t.Errorf("Trace(ns:y) = %+v, want from a", tr)
+		}
+		want := []SourceReport{
+			{Label: "a", Priority: 0, Provided: []string{"ns:x", "ns:y"}, Won: []string{"ns:y"}},
+			{Label: "b", Priority: 0, Provided: []string{"ns:x"}, Won: []string{}},
+			{Label: "c", Priority: 5, Provided: []string{"ns:x"}, Won: []string{"ns:x"}},
+		}
+		if !reflect.DeepEqual(report.Sources, want) {
+			t.Errorf("Sources = %+v, want %+v", report.Sources, want)
+		}
+		if out := report.String(); !strings.Contains(out, "c (priority 5): provided 1, won 1") {
+			t.Errorf("String() missing source summary:\n%s", out)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package toolset
+
+import (
+	"fmt"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// ConflictResolver decides which tool to keep when two toolsets carry
+// different tools under the same ID. It is called with the tool accumulated
+// so far and the incoming one, in argument order. Returning a nil tool drops
+// the ID from the result (later toolsets cannot restore it); returning an
+// error aborts the operation. A returned tool must have the conflicting ID;
+// otherwise the operation fails.
+//
+// Resolvers are only consulted for genuine conflicts: identical tools (same
+// pointer or equal definitions) are merged silently.
+type ConflictResolver func(id string, existing, incoming *tooladapter.CanonicalTool) (*tooladapter.CanonicalTool, error)
+
+// FirstWins keeps the tool from the earliest toolset.
+func FirstWins() ConflictResolver {
+	return func(_ string, existing, _ *tooladapter.CanonicalTool) (*tooladapter.CanonicalTool, error) {
+		return existing, nil
+	}
+}
+
+// LastWins keeps the tool from the latest toolset.
+func LastWins() ConflictResolver {
+	return func(_ string, _, incoming *tooladapter.CanonicalTool) (*tooladapter.CanonicalTool, error) {
+		return incoming, nil
+	}
+}
+
+// ErrorOnConflict fails with a *ConflictError on the first conflict.
+func ErrorOnConflict() ConflictResolver {
+	return func(id string, existing, incoming *tooladapter.CanonicalTool) (*tooladapter.CanonicalTool, error) {
+		return nil, &ConflictError{ID: id, Existing: existing, Incoming: incoming}
+	}
+}
+
+// ConflictError reports two different tools under the same ID.
+type ConflictError struct {
+	ID       string
+	Existing *tooladapter.CanonicalTool
+	Incoming *tooladapter.CanonicalTool
+}
+
+func (e *ConflictError) Error() string {
+	return "conflicting definitions for tool " + e.ID
+}
+
+// Union returns a toolset named name containing every tool in any of sets.
+// Conflicts are settled by resolve (FirstWins if nil). Nil sets are skipped.
+func Union(name string, resolve ConflictResolver, sets ...*Toolset) (*Toolset, error) {
+	merged, err := mergeSets(resolve, sets, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newFromMerge(name, merged), nil
+}
+
+// Intersect returns a toolset named name containing the tools whose IDs are
+// present in every one of sets. Conflicts are settled by resolve (FirstWins
+// if nil). A nil set is treated as empty, and no sets yield an empty result.
+func Intersect(name string, resolve ConflictResolver, sets ...*Toolset) (*Toolset, error) {
+	if len(sets) == 0 {
+		return New(name), nil
+	}
+	counts := make(map[string]int)
+	for _, ts := range sets {
+		if ts == nil {
+			return New(name), nil
+		}
+		for _, id := range ts.IDs() {
+			counts[id]++
+		}
+	}
+	keep := func(id string) bool { return counts[id] == len(sets) }
+	merged, err := mergeSets(resolve, sets, keep)
+	if err != nil {
+		return nil, err
+	}
+	return newFromMerge(name, merged), nil
+}
+
+// Subtract returns a toolset named name containing the tools of from whose
+// IDs appear in none of others. Nil toolsets are treated as empty.
+func Subtract(name string, from *Toolset, others ...*Toolset) *Toolset {
+	if from == nil {
+		return New(name)
+	}
+	drop := make(map[string]bool)
+	for _, ts := range others {
+		if ts == nil {
+			continue
+		}
+		for _, id := range ts.IDs() {
+			drop[id] = true
+		}
+	}
+	var kept []*tooladapter.CanonicalTool
+	for _, t := range from.Snapshot().tools {
+		if !drop[t.ID()] {
+			kept = append(kept, t)
+		}
+	}
+	return newFromTools(name, kept)
+}
+
+// mergeSets folds the tools of sets in order, keeping IDs accepted by keep
+// (all IDs if keep is nil) and settling conflicts with resolve.
+func mergeSets(resolve ConflictResolver, sets []*Toolset, keep func(string) bool) (map[string]*tooladapter.CanonicalTool, error) {
+	if resolve == nil {
+		resolve = FirstWins()
+	}
+	merged := make(map[string]*tooladapter.CanonicalTool)
+	for _, ts := range sets {
+		if ts == nil {
+			continue
+		}
+		for _, t := range ts.Tools() {
+			id := t.ID()
+			if keep != nil && !keep(id) {
+				continue
+			}
+			existing, ok := merged[id]
+			if !ok {
+				merged[id] = t
+				continue
+			}
+			if existing == nil || sameTool(existing, t) {
+				continue
+			}
+			winner, err := resolve(id, existing, t)
+			if err != nil {
+				return nil, err
+			}
+			if winner != nil && winner.ID() != id {
+				return nil, fmt.Errorf("conflict resolver returned tool %s for conflicting tool %s", winner.ID(), id)
+			}
+			merged[id] = winner
+		}
+	}
+	return merged, nil
+}
+
+// newFromMerge builds a toolset from merged tools, skipping dropped IDs.
+func newFromMerge(name string, merged map[string]*tooladapter.CanonicalTool) *Toolset {
+	tools := make([]*tooladapter.CanonicalTool, 0, len(merged))
+	for _, t := range merged {
+		tools = append(tools, t) // nil entries are skipped by newFromTools
+	}
+	return newFromTools(name, tools)
+}
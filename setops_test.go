@@ -0,0 +1,153 @@
+package toolset
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+func setOf(name string, tools ...*tooladapter.CanonicalTool) *Toolset {
+	ts := New(name)
+	for _, t := range tools {
+		ts.Add(t)
+	}
+	return ts
+}
+
+func TestUnion(t *testing.T) {
+	a1 := makeTool("ns", "a", []string{"v1"})
+	a2 := makeTool("ns", "a", []string{"v2"})
+	left := setOf("left", a1, makeTool("ns", "b", nil))
+	right := setOf("right", a2, makeTool("ns", "c", nil))
+
+	t.Run("first wins by default", func(t *testing.T) {
+		u, err := Union("all", nil, left, nil, right)
+		if err != nil {
+			t.Fatalf("Union() error = %v", err)
+		}
+		if u.Name() != "all" {
+			t.Errorf("Name() = %q", u.Name())
+		}
+		if !reflect.DeepEqual(u.IDs(), []string{"ns:a", "ns:b", "ns:c"}) {
+			t.Errorf("IDs() = %v", u.IDs())
+		}
+		if got, _ := u.Get("ns:a"); got != a1 {
+			t.Error("FirstWins should keep the left tool")
+		}
+	})
+
+	t.Run("last wins", func(t *testing.T) {
+		u, _ := Union("all", LastWins(), left, right)
+		if got, _ := u.Get("ns:a"); got != a2 {
+			t.Error("LastWins should keep the right tool")
+		}
+	})
+
+	t.Run("error on conflict", func(t *testing.T) {
+		_, err := Union("all", ErrorOnConflict(), left, right)
+		var conflict *ConflictError
+		if !errors.As(err, &conflict) || conflict.ID != "ns:a" {
+			t.Fatalf("Union() error = %v, want ConflictError for ns:a", err)
+		}
+		if conflict.Existing != a1 || conflict.Incoming != a2 {
+			t.Error("ConflictError should carry both tools")
+		}
+	})
+
+	t.Run("identical tools are not conflicts", func(t *testing.T) {
+		copyA := makeTool("ns", "a", []string{"v1"})
+		_, err := Union("all", ErrorOnConflict(), left, setOf("copy", copyA))
+		if err != nil {
+			t.Errorf("Union() error = %v, want nil", err)
+		}
+	})
+
+	t.Run("custom resolver", func(t *testing.T) {
+		merged := makeTool("ns", "a", []string{"v1", "v2"})
+		var calls int
+		u, err := Union("all", func(id string, existing, incoming *tooladapter.CanonicalTool) (*tooladapter.CanonicalTool, error) {
+			calls++
+			return merged, nil
+		}, left, right)
+		if err != nil {
+			t.Fatalf("Union() error = %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("resolver called %d times, want 1", calls)
+		}
+		if got, _ := u.Get("ns:a"); got != merged {
+			t.Error("custom resolver result not used")
+		}
+	})
+
+	t.Run("resolver can drop", func(t *testing.T) {
+		drop := func(string, *tooladapter.CanonicalTool, *tooladapter.CanonicalTool) (*tooladapter.CanonicalTool, error) {
+			return nil, nil
+		}
+		u, _ := Union("all", drop, left, right, setOf("again", a1))
+		if _, ok := u.Get("ns:a"); ok {
+			t.Error("dropped tool should not be present")
+		}
+	})
+
+	t.Run("resolver must keep the ID", func(t *testing.T) {
+		other := func(string, *tooladapter.CanonicalTool, *tooladapter.CanonicalTool) (*tooladapter.CanonicalTool, error) {
+			return makeTool("ns", "b", []string{"hijacked"}), nil
+		}
+		if _, err := Union("all", other, left, right); err == nil {
+			t.Error("Union() should fail when the resolver returns another ID")
+		}
+	})
+
+	t.Run("inputs unchanged", func(t *testing.T) {
+		_, _ = Union("all", nil, left, right)
+		if left.Count() != 2 || right.Count() != 2 {
+			t.Error("Union should not modify inputs")
+		}
+	})
+}
+
+func TestIntersect(t *testing.T) {
+	a1 := makeTool("ns", "a", []string{"v1"})
+	a2 := makeTool("ns", "a", []string{"v2"})
+	x := setOf("x", a1, makeTool("ns", "b", nil), makeTool("ns", "c", nil))
+	y := setOf("y", a2, makeTool("ns", "b", nil))
+	z := setOf("z", makeTool("ns", "a", nil), makeTool("ns", "b", nil), makeTool("ns", "d", nil))
+
+	i, err := Intersect("common", nil, x, y, z)
+	if err != nil {
+		t.Fatalf("Intersect() error = %v", err)
+	}
+	if !reflect.DeepEqual(i.IDs(), []string{"ns:a", "ns:b"}) {
+		t.Errorf("IDs() = %v", i.IDs())
+	}
+	if got, _ := i.Get("ns:a"); got != a1 {
+		t.Error("FirstWins should keep the first tool")
+	}
+
+	if _, err := Intersect("common", ErrorOnConflict(), x, y); err == nil {
+		t.Error("expected conflict error")
+	}
+	if got, _ := Intersect("none", nil); got.Count() != 0 {
+		t.Error("Intersect of nothing should be empty")
+	}
+	if got, _ := Intersect("nil", nil, x, nil); got.Count() != 0 {
+		t.Error("Intersect with nil set should be empty")
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	x := setOf("x", makeTool("ns", "a", nil), makeTool("ns", "b", nil), makeTool("ns", "c", nil))
+	y := setOf("y", makeTool("ns", "b", []string{"different"}))
+	z := setOf("z", makeTool("ns", "c", nil))
+
+	d := Subtract("rest", x, y, nil, z)
+	if d.Name() != "rest" || !reflect.DeepEqual(d.IDs(), []string{"ns:a"}) {
+		t.Errorf("Subtract() = %q %v", d.Name(), d.IDs())
+	}
+	if Subtract("empty", nil, x).Count() != 0 {
+		t.Error("Subtract from nil should be empty")
+	}
+}
@@ -0,0 +1,132 @@
+package toolset
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// Snapshot is an immutable view of a Toolset at one point in time, sorted
+// by tool ID. It is safe to share across goroutines: reading it takes no
+// locks and later mutations of the toolset publish a new snapshot instead
+// of changing this one.
+type Snapshot struct {
+	name    string
+	version uint64
+	tools   []*tooladapter.CanonicalTool // sorted by ID; never modified
+	ids     []string                     // parallel to tools
+
+	etagOnce sync.Once
+	etag     string
+
+	fingerprintOnce sync.Once
+	fingerprint     string
+}
+
+// Name returns the name of the toolset the snapshot was taken from.
+func (s *Snapshot) Name() string { return s.name }
+
+// Version returns the snapshot version. Each mutation of a toolset
+// publishes a snapshot with a higher version; a new toolset starts at 0.
+func (s *Snapshot) Version() uint64 { return s.version }
+
+// Len returns the number of tools.
+func (s *Snapshot) Len() int { return len(s.tools) }
+
+// At returns the i-th tool in ID order. It panics if i is out of range.
+func (s *Snapshot) At(i int) *tooladapter.CanonicalTool { return s.tools[i] }
+
+// Get retrieves a tool by ID. Returns (nil, false) if not found.
+func (s *Snapshot) Get(id string) (*tooladapter.CanonicalTool, bool) {
+	i, ok := s.search(id)
+	if !ok {
+		return nil, false
+	}
+	return s.tools[i], true
+}
+
+// Tools returns the tools sorted by ID. The slice is caller-owned; the
+// tools are shared and read-only.
+func (s *Snapshot) Tools() []*tooladapter.CanonicalTool {
+	return append(make([]*tooladapter.CanonicalTool, 0, len(s.tools)), s.tools...)
+}
+
+// IDs returns the tool IDs sorted lexicographically.
+func (s *Snapshot) IDs() []string {
+	return append(make([]string, 0, len(s.ids)), s.ids...)
+}
+
+// search returns the position of id, or where it would be inserted.
+func (s *Snapshot) search(id string) (int, bool) {
+	i := sort.SearchStrings(s.ids, id)
+	return i, i < len(s.ids) && s.ids[i] == id
+}
+
+// with returns the next snapshot with t added at position i, replacing the
+// tool there if replace is set.
+func (s *Snapshot) with(i int, t *tooladapter.CanonicalTool, replace bool) *Snapshot {
+	next := &Snapshot{name: s.name, version: s.version + 1}
+	if replace {
+		next.tools = append([]*tooladapter.CanonicalTool(nil), s.tools...)
+		next.tools[i] = t
+		next.ids = s.ids // unchanged, shared
+		return next
+	}
+	next.tools = make([]*tooladapter.CanonicalTool, 0, len(s.tools)+1)
+	next.tools = append(append(append(next.tools, s.tools[:i]...), t), s.tools[i:]...)
+	next.ids = make([]string, 0, len(s.ids)+1)
+	next.ids = append(append(append(next.ids, s.ids[:i]...), t.ID()), s.ids[i:]...)
+	return next
+}
+
+// without returns the next snapshot with the tool at position i removed.
+func (s *Snapshot) without(i int) *Snapshot {
+	next := &Snapshot{name: s.name, version: s.version + 1}
+	next.tools = append(append(make([]*tooladapter.CanonicalTool, 0, len(s.tools)-1), s.tools[:i]...), s.tools[i+1:]...)
+	next.ids = append(append(make([]string, 0, len(s.ids)-1), s.ids[:i]...), s.ids[i+1:]...)
+	return next
+}
+
+// newSnapshot builds a version 0 snapshot from tools. For duplicate IDs the
+// last tool wins; nil tools are skipped.
+func newSnapshot(name string, tools []*tooladapter.CanonicalTool) *Snapshot {
+	byID := make(map[string]*tooladapter.CanonicalTool, len(tools))
+	for _, t := range tools {
+		if t != nil {
+			byID[t.ID()] = t
+		}
+	}
+	s := &Snapshot{
+		name:  name,
+		tools: make([]*tooladapter.CanonicalTool, 0, len(byID)),
+		ids:   make([]string, 0, len(byID)),
+	}
+	for id := range byID {
+		s.ids = append(s.ids, id)
+	}
+	sort.Strings(s.ids)
+	for _, id := range s.ids {
+		s.tools = append(s.tools, byID[id])
+	}
+	return s
+}
+
+// emptySnapshot is the snapshot of a zero Toolset before its first mutation.
+var emptySnapshot = newSnapshot("", nil)
+
+// Snapshot returns the current snapshot. It never blocks on writers.
+func (ts *Toolset) Snapshot() *Snapshot {
+	if s := ts.snap.Load(); s != nil {
+		return s
+	}
+	return emptySnapshot
+}
+
+// newFromTools creates a Toolset holding tools, publishing a single
+// snapshot instead of one per tool.
+func newFromTools(name string, tools []*tooladapter.CanonicalTool) *Toolset {
+	ts := &Toolset{name: name}
+	ts.snap.Store(newSnapshot(name, tools))
+	return ts
+}
@@ -0,0 +1,103 @@
+package toolset
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestToolset_Snapshot(t *testing.T) {
+	t.Run("is sorted and versioned", func(t *testing.T) {
+		ts := New("snap")
+		if v := ts.Snapshot().Version(); v != 0 {
+			t.Errorf("new toolset version = %d, want 0", v)
+		}
+		ts.Add(makeTool("ns", "b", nil))
+		ts.Add(makeTool("ns", "a", nil))
+		s := ts.Snapshot()
+		if s.Version() != 2 || s.Len() != 2 || s.Name() != "snap" {
+			t.Errorf("snapshot = version %d, len %d, name %q", s.Version(), s.Len(), s.Name())
+		}
+		if s.At(0).ID() != "ns:a" || !reflect.DeepEqual(s.IDs(), []string{"ns:a", "ns:b"}) {
+			t.Errorf("IDs() = %v", s.IDs())
+		}
+		if tool, ok := s.Get("ns:b"); !ok || tool.Name != "b" {
+			t.Errorf("Get(ns:b) = %v, %v", tool, ok)
+		}
+		if _, ok := s.Get("ns:c"); ok {
+			t.Error("Get(ns:c) found a missing tool")
+		}
+	})
+
+	t.Run("is not changed by later mutations", func(t *testing.T) {
+		ts := New("snap")
+		a := makeTool("ns", "a", nil)
+		ts.Add(a)
+		before := ts.Snapshot()
+
+		ts.Add(makeTool("ns", "b", nil))
+		ts.Add(makeTool("ns", "a", []string{"v2"}))
+		ts.Remove("ns:b")
+
+		if before.Len() != 1 || before.At(0) != a || before.Version() != 1 {
+			t.Errorf("old snapshot changed: %v, version %d", before.IDs(), before.Version())
+		}
+		if after := ts.Snapshot(); after.Version() != 4 || after.At(0) == a {
+			t.Errorf("new snapshot = version %d, first %v", after.Version(), after.At(0))
+		}
+	})
+
+	t.Run("no-op mutations publish nothing", func(t *testing.T) {
+		ts := New("snap")
+		a := makeTool("ns", "a", nil)
+		ts.Add(a)
+		s := ts.Snapshot()
+		ts.Add(a)
+		ts.Remove("ns:missing")
+		ts.Add(nil)
+		if ts.Snapshot() != s {
+			t.Error("no-op mutation published a new snapshot")
+		}
+	})
+
+	t.Run("returned slices are caller-owned", func(t *testing.T) {
+		ts := pagedToolset("a", "b")
+		s := ts.Snapshot()
+		tools, ids := s.Tools(), s.IDs()
+		tools[0], ids[0] = nil, "x"
+		if s.At(0) == nil || s.IDs()[0] != "ns:a" || ts.Tools()[0] == nil {
+			t.Error("modifying returned slices changed the snapshot")
+		}
+	})
+
+	t.Run("concurrent readers and writers", func(t *testing.T) {
+		ts := New("snap")
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(2)
+			go func(w int) {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					ts.Add(makeTool("ns", string(rune('a'+w))+string(rune('a'+i%26)), nil))
+				}
+			}(w)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					s := ts.Snapshot()
+					ids := s.IDs()
+					for j := 1; j < len(ids); j++ {
+						if ids[j-1] >= ids[j] {
+							t.Errorf("snapshot not sorted: %v", ids)
+							return
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		if ts.Count() != 4*26 {
+			t.Errorf("Count() = %d, want %d", ts.Count(), 4*26)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package toolset
+
+import (
+	"context"
+
+	"github.com/jonwraymond/tooladapter"
+)
+
+// builderSource is a labelled tool source of a Builder.
+type builderSource struct {
+	label     string
+	priority  int
+	registry  ContextRegistry
+	origin    any  // registry as supplied, for watch detection
+	anonymous bool // installed by FromTools or FromRegistry
+}
+
+// staticRegistry adapts a fixed tool slice to Registry.
+type staticRegistry []*tooladapter.CanonicalTool
+
+func (s staticRegistry) Tools() []*tooladapter.CanonicalTool { return s }
+
+// toolOrigin records which source provided a tool and which lost to it.
+type toolOrigin struct {
+	source   int   // index into the Builder's sources
+	shadowed []int // sources whose tool with the same ID lost
+}
+
+// gatherSources reads every source in order and resolves duplicate IDs:
+// higher priority wins, then the earlier source, then the later tool within
+// a source. It returns one tool per ID, in first-seen order, and the origin
+// of each ID. The first failing source aborts with a *SourceError.
+func gatherSources(ctx context.Context, sources []builderSource) ([]*tooladapter.CanonicalTool, map[string]*toolOrigin, error) {
+	chosen := make(map[string]*tooladapter.CanonicalTool)
+	origins := make(map[string]*toolOrigin)
+	var order []string
+
+	for i, src := range sources {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, &SourceError{Label: src.label, Err: err}
+		}
+		provided, err := src.registry.Tools(ctx)
+		if err != nil {
+			return nil, nil, &SourceError{Label: src.label, Err: err}
+		}
+		for _, t := range provided {
+			if t == nil {
+				continue
+			}
+			id := t.ID()
+			origin, ok := origins[id]
+			switch {
+			case !ok:
+				chosen[id] = t
+				origins[id] = &toolOrigin{source: i}
+				order = append(order, id)
+			case origin.source == i:
+				chosen[id] = t
+			case src.priority > sources[origin.source].priority:
+				origin.shadowed = append(origin.shadowed, origin.source)
+				origin.source = i
+				chosen[id] = t
+			default:
+				origin.shadowed = append(origin.shadowed, i)
+			}
+		}
+	}
+
+	tools := make([]*tooladapter.CanonicalTool, len(order))
+	for i, id := range order {
+		tools[i] = chosen[id]
+	}
+	return tools, origins, nil
+}
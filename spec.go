@@ -0,0 +1,428 @@
+package toolset
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jonwraymond/tooladapter"
+	"go.yaml.in/yaml/v3"
+)
+
+// Spec is a declarative toolset definition.
+//
+// A Spec compiles into a configured Builder (see Spec.Builder) and can be
+// recovered from one (see Builder.Spec). It marshals to JSON and YAML via
+// its struct tags; use ParseSpec or LoadSpec to read it back with strict
+// validation.
+//
+// Empty lists are treated as unset. All configured criteria are AND-composed.
+type Spec struct {
+	// Name is the toolset name (required).
+	Name string `json:"name" yaml:"name"`
+
+	// Sources names the registries to draw tools from, resolved by the caller.
+	Sources []string `json:"sources,omitempty" yaml:"sources,omitempty"`
+
+	// Namespaces restricts tools by namespace.
+	Namespaces *NamespaceSpec `json:"namespaces,omitempty" yaml:"namespaces,omitempty"`
+
+	// Tags restricts tools by tag.
+	Tags *TagSpec `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Categories keeps tools in any of the categories.
+	Categories []string `json:"categories,omitempty" yaml:"categories,omitempty"`
+
+	// Tools lists explicit tool IDs to allow or deny.
+	Tools *ToolIDSpec `json:"tools,omitempty" yaml:"tools,omitempty"`
+
+	// Expression is a filter expression (see ParseExpression).
+	Expression string `json:"expression,omitempty" yaml:"expression,omitempty"`
+
+	// Policy is applied after all filters.
+	Policy *PolicySpec `json:"policy,omitempty" yaml:"policy,omitempty"`
+}
+
+// NamespaceSpec selects tools by namespace.
+type NamespaceSpec struct {
+	// Include keeps only tools in any of these namespaces.
+	Include []string `json:"include,omitempty" yaml:"include,omitempty"`
+
+	// Exclude drops tools in any of these namespaces.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty"`
+}
+
+// TagSpec selects tools by tag.
+type TagSpec struct {
+	// Any keeps tools with at least one of these tags.
+	Any []string `json:"any,omitempty" yaml:"any,omitempty"`
+
+	// All keeps tools with every one of these tags.
+	All []string `json:"all,omitempty" yaml:"all,omitempty"`
+
+	// None drops tools with any of these tags.
+	None []string `json:"none,omitempty" yaml:"none,omitempty"`
+}
+
+// ToolIDSpec selects tools by ID.
+type ToolIDSpec struct {
+	// Allow keeps only the listed tool IDs.
+	Allow []string `json:"allow,omitempty" yaml:"allow,omitempty"`
+
+	// Deny drops the listed tool IDs.
+	Deny []string `json:"deny,omitempty" yaml:"deny,omitempty"`
+}
+
+// PolicySpec declares the built-in policies to apply. A tool must satisfy
+// every configured rule.
+type PolicySpec struct {
+	// AllowNamespaces allows only tools in these namespaces.
+	AllowNamespaces []string `json:"allow_namespaces,omitempty" yaml:"allow_namespaces,omitempty"`
+
+	// DenyTags denies tools with any of these tags.
+	DenyTags []string `json:"deny_tags,omitempty" yaml:"deny_tags,omitempty"`
+
+	// AllowScopes allows only tools whose required scopes are all listed.
+	AllowScopes []string `json:"allow_scopes,omitempty" yaml:"allow_scopes,omitempty"`
+}
+
+// ParseSpec decodes a Spec from YAML or JSON and validates it.
+// Unknown fields, wrong types and invalid values are reported together as
+// ValidationErrors with the path (and line, where known) of each problem.
+func ParseSpec(data []byte) (*Spec, error) {
+	root, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &nodeDecoder{}
+	s := &Spec{}
+	d.mapping(root, "", map[string]func(*yaml.Node, string){
+		"name":       func(n *yaml.Node, p string) { s.Name = d.str(n, p) },
+		"sources":    func(n *yaml.Node, p string) { s.Sources = d.strs(n, p) },
+		"categories": func(n *yaml.Node, p string) { s.Categories = d.strs(n, p) },
+		"expression": func(n *yaml.Node, p string) { s.Expression = d.str(n, p) },
+		"namespaces": func(n *yaml.Node, p string) {
+			s.Namespaces = &NamespaceSpec{}
+			d.mapping(n, p, map[string]func(*yaml.Node, string){
+				"include": func(n *yaml.Node, p string) { s.Namespaces.Include = d.strs(n, p) },
+				"exclude": func(n *yaml.Node, p string) { s.Namespaces.Exclude = d.strs(n, p) },
+			})
+		},
+		"tags": func(n *yaml.Node, p string) {
+			s.Tags = &TagSpec{}
+			d.mapping(n, p, map[string]func(*yaml.Node, string){
+				"any":  func(n *yaml.Node, p string) { s.Tags.Any = d.strs(n, p) },
+				"all":  func(n *yaml.Node, p string) { s.Tags.All = d.strs(n, p) },
+				"none": func(n *yaml.Node, p string) { s.Tags.None = d.strs(n, p) },
+			})
+		},
+		"tools": func(n *yaml.Node, p string) {
+			s.Tools = &ToolIDSpec{}
+			d.mapping(n, p, map[string]func(*yaml.Node, string){
+				"allow": func(n *yaml.Node, p string) { s.Tools.Allow = d.strs(n, p) },
+				"deny":  func(n *yaml.Node, p string) { s.Tools.Deny = d.strs(n, p) },
+			})
+		},
+		"policy": func(n *yaml.Node, p string) {
+			s.Policy = &PolicySpec{}
+			d.mapping(n, p, map[string]func(*yaml.Node, string){
+				"allow_namespaces": func(n *yaml.Node, p string) { s.Policy.AllowNamespaces = d.strs(n, p) },
+				"deny_tags":        func(n *yaml.Node, p string) { s.Policy.DenyTags = d.strs(n, p) },
+				"allow_scopes":     func(n *yaml.Node, p string) { s.Policy.AllowScopes = d.strs(n, p) },
+			})
+		},
+	})
+	if err := d.errs.err(); err != nil {
+		return nil, err
+	}
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// LoadSpec reads a YAML or JSON Spec from r and validates it.
+func LoadSpec(r io.Reader) (*Spec, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseSpec(data)
+}
+
+// Validate checks the spec for semantic errors.
+// It returns ValidationErrors listing every problem found.
+func (s *Spec) Validate() error {
+	var errs ValidationErrors
+	if s.Name == "" {
+		errs = append(errs, &FieldError{Path: "name", Msg: "is required"})
+	}
+	checkStrings(&errs, "sources", s.Sources)
+	checkStrings(&errs, "categories", s.Categories)
+	if s.Namespaces != nil {
+		checkStrings(&errs, "namespaces.include", s.Namespaces.Include)
+		checkStrings(&errs, "namespaces.exclude", s.Namespaces.Exclude)
+	}
+	if s.Tags != nil {
+		checkStrings(&errs, "tags.any", s.Tags.Any)
+		checkStrings(&errs, "tags.all", s.Tags.All)
+		checkStrings(&errs, "tags.none", s.Tags.None)
+	}
+	if s.Tools != nil {
+		checkStrings(&errs, "tools.allow", s.Tools.Allow)
+		checkStrings(&errs, "tools.deny", s.Tools.Deny)
+	}
+	if s.Expression != "" {
+		if _, err := ParseExpression(s.Expression); err != nil {
+			errs = append(errs, &FieldError{Path: "expression", Msg: err.Error()})
+		}
+	}
+	if s.Policy != nil {
+		checkStrings(&errs, "policy.allow_namespaces", s.Policy.AllowNamespaces)
+		checkStrings(&errs, "policy.deny_tags", s.Policy.DenyTags)
+		checkStrings(&errs, "policy.allow_scopes", s.Policy.AllowScopes)
+	}
+	return errs.err()
+}
+
+// Builder validates the spec and returns a Builder configured from it.
+//
+// Each name in Sources is looked up in sources and added with AddSource at
+// priority 0, so for duplicate IDs the first listed source wins. Unknown
+// names are an error. A spec without sources yields a Builder with no
+// source, which the caller supplies with FromTools or FromRegistry before
+// building.
+func (s *Spec) Builder(sources map[string]Registry) (*Builder, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	b := NewBuilder(s.Name)
+	var errs ValidationErrors
+	for i, name := range s.Sources {
+		r, ok := sources[name]
+		if !ok || r == nil {
+			errs = append(errs, &FieldError{Path: indexPath("sources", i), Msg: fmt.Sprintf("unknown source %q", name)})
+			continue
+		}
+		b.AddSource(name, 0, r)
+	}
+	if err := errs.err(); err != nil {
+		return nil, err
+	}
+
+	if ns := s.Namespaces; ns != nil {
+		if len(ns.Include) > 0 {
+			b.WithNamespaces(ns.Include)
+		}
+		if len(ns.Exclude) > 0 {
+			b.ExcludeNamespaces(ns.Exclude)
+		}
+	}
+	if tags := s.Tags; tags != nil {
+		if len(tags.Any) > 0 {
+			b.WithAnyTags(tags.Any)
+		}
+		if len(tags.All) > 0 {
+			b.WithTags(tags.All)
+		}
+		if len(tags.None) > 0 {
+			b.ExcludeTags(tags.None)
+		}
+	}
+	if len(s.Categories) > 0 {
+		b.WithCategories(s.Categories)
+	}
+	if ids := s.Tools; ids != nil {
+		if len(ids.Allow) > 0 {
+			b.WithTools(ids.Allow)
+		}
+		if len(ids.Deny) > 0 {
+			b.ExcludeTools(ids.Deny)
+		}
+	}
+	if s.Expression != "" {
+		b.WithExpression(s.Expression)
+	}
+	if s.Policy != nil && !s.Policy.empty() {
+		b.WithPolicy(s.Policy.Policy())
+	}
+	return b, nil
+}
+
+// Spec returns the Builder's configuration as a Spec.
+//
+// Only configuration made through the declarative Builder methods can be
+// represented. Custom filters (WithFilter, WithAnyOf, WithNoneOf), policies
+// not created by PolicySpec.Policy, and combinations a Spec cannot express
+// are reported as errors. Sources added with AddSource or AddTools are
+// recorded by label and must have priority 0; FromTools and FromRegistry
+// sources are not recorded.
+func (b *Builder) Spec() (*Spec, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	s := &Spec{Name: b.name}
+	for _, src := range b.sources {
+		if src.anonymous {
+			continue
+		}
+		if src.priority != 0 {
+			return nil, fmt.Errorf("source %q: priority %d cannot be represented in a spec", src.label, src.priority)
+		}
+		s.Sources = append(s.Sources, src.label)
+	}
+	var include, exclude, tagsAny, tagsAll, tagsNone, categories, allow, deny []string
+	var exprs []string
+	var includeSet, tagsAnySet, categoriesSet, allowSet bool
+
+	for i, f := range b.filters {
+		switch f.kind {
+		case "namespace":
+			include, includeSet = intersectOrSet(include, f.args, includeSet)
+		case "exclude-namespace":
+			exclude = unionStrings(exclude, f.args)
+		case "tags-any":
+			if tagsAnySet {
+				return nil, fmt.Errorf("filter[%d] %s: a spec can hold only one tags.any group", i, f.label())
+			}
+			tagsAny, tagsAnySet = f.args, true
+		case "tags-all":
+			tagsAll = unionStrings(tagsAll, f.args)
+		case "tags-none":
+			tagsNone = unionStrings(tagsNone, f.args)
+		case "category":
+			categories, categoriesSet = intersectOrSet(categories, f.args, categoriesSet)
+		case "allow-ids":
+			allow, allowSet = intersectOrSet(allow, f.args, allowSet)
+		case "deny-ids":
+			deny = unionStrings(deny, f.args)
+		case "expr":
+			exprs = append(exprs, f.args[0])
+		default:
+			return nil, fmt.Errorf("filter[%d] %s: custom filters cannot be represented in a spec", i, f.label())
+		}
+	}
+	for _, group := range []struct {
+		field  string
+		values []string
+		set    bool
+	}{
+		{"namespaces.include", include, includeSet},
+		{"tags.any", tagsAny, tagsAnySet},
+		{"categories", categories, categoriesSet},
+		{"tools.allow", allow, allowSet},
+	} {
+		if group.set && len(group.values) == 0 {
+			return nil, fmt.Errorf("%s: filters match no tools, which a spec cannot represent", group.field)
+		}
+	}
+
+	if len(include) > 0 || len(exclude) > 0 {
+		s.Namespaces = &NamespaceSpec{Include: include, Exclude: exclude}
+	}
+	if len(tagsAny) > 0 || len(tagsAll) > 0 || len(tagsNone) > 0 {
+		s.Tags = &TagSpec{Any: tagsAny, All: tagsAll, None: tagsNone}
+	}
+	s.Categories = categories
+	if len(allow) > 0 || len(deny) > 0 {
+		s.Tools = &ToolIDSpec{Allow: allow, Deny: deny}
+	}
+	switch len(exprs) {
+	case 0:
+	case 1:
+		s.Expression = exprs[0]
+	default:
+		s.Expression = "(" + strings.Join(exprs, ") && (") + ")"
+	}
+
+	switch p := b.policy().(type) {
+	case nil:
+	case *specPolicy:
+		spec := p.spec
+		s.Policy = &spec
+	default:
+		return nil, fmt.Errorf("policy %T cannot be represented in a spec", p)
+	}
+	return s, nil
+}
+
+// Policy compiles the spec into a Policy. Rules are evaluated in the order
+// allow_namespaces, deny_tags, allow_scopes; the first deny decides.
+func (ps PolicySpec) Policy() Policy {
+	p := &specPolicy{spec: ps}
+	if len(ps.AllowNamespaces) > 0 {
+		p.rules = append(p.rules, Explain(AllowNamespaces(ps.AllowNamespaces...)))
+	}
+	if len(ps.DenyTags) > 0 {
+		p.rules = append(p.rules, Explain(DenyTags(ps.DenyTags...)))
+	}
+	if len(ps.AllowScopes) > 0 {
+		p.rules = append(p.rules, Explain(AllowScopes(ps.AllowScopes...)))
+	}
+	return p
+}
+
+func (ps PolicySpec) empty() bool {
+	return len(ps.AllowNamespaces) == 0 && len(ps.DenyTags) == 0 && len(ps.AllowScopes) == 0
+}
+
+// specPolicy is the Policy compiled from a PolicySpec. It retains the spec
+// so that Builder.Spec can write it back out.
+type specPolicy struct {
+	spec  PolicySpec
+	rules []DecisionPolicy
+}
+
+// Decide implements DecisionPolicy.
+func (p *specPolicy) Decide(t *tooladapter.CanonicalTool) Decision {
+	if t == nil {
+		return nilToolDecision
+	}
+	for _, rule := range p.rules {
+		if d := rule.Decide(t); !d.Allowed() {
+			return d
+		}
+	}
+	return Decision{Effect: EffectAllow, Rule: "policy-spec", Reason: "all policy rules allow the tool"}
+}
+
+// Allow implements Policy.
+func (p *specPolicy) Allow(t *tooladapter.CanonicalTool) bool {
+	return p.Decide(t).Allowed()
+}
+
+// intersectOrSet intersects cur with next, or adopts next on first use.
+func intersectOrSet(cur, next []string, set bool) ([]string, bool) {
+	if !set {
+		return append([]string(nil), next...), true
+	}
+	keep := make(map[string]bool, len(next))
+	for _, v := range next {
+		keep[v] = true
+	}
+	out := cur[:0:0]
+	for _, v := range cur {
+		if keep[v] {
+			out = append(out, v)
+		}
+	}
+	return out, true
+}
+
+// unionStrings appends the values of next not already in cur.
+func unionStrings(cur, next []string) []string {
+	seen := make(map[string]bool, len(cur)+len(next))
+	for _, v := range cur {
+		seen[v] = true
+	}
+	for _, v := range next {
+		if !seen[v] {
+			seen[v] = true
+			cur = append(cur, v)
+		}
+	}
+	return cur
+}
@@ -0,0 +1,312 @@
+package toolset
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/tooladapter"
+	"go.yaml.in/yaml/v3"
+)
+
+const mcpSafeSpecYAML = `
+name: mcp-safe
+sources: [all]
+namespaces:
+  include: [mcp]
+tags:
+  all: [safe]
+  none: [danger]
+tools:
+  deny: ["mcp:fetch"]
+policy:
+  deny_tags: [write]
+`
+
+func TestParseSpec(t *testing.T) {
+	t.Run("YAML", func(t *testing.T) {
+		s, err := ParseSpec([]byte(mcpSafeSpecYAML))
+		if err != nil {
+			t.Fatalf("ParseSpec() error = %v", err)
+		}
+		want := &Spec{
+			Name:       "mcp-safe",
+			Sources:    []string{"all"},
+			Namespaces: &NamespaceSpec{Include: []string{"mcp"}},
+			Tags:       &TagSpec{All: []string{"safe"}, None: []string{"danger"}},
+			Tools:      &ToolIDSpec{Deny: []string{"mcp:fetch"}},
+			Policy:     &PolicySpec{DenyTags: []string{"write"}},
+		}
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("ParseSpec() = %+v, want %+v", s, want)
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		s, err := ParseSpec([]byte(`{"name": "gh", "categories": ["query"], "expression": "tags has \"read\""}`))
+		if err != nil {
+			t.Fatalf("ParseSpec() error = %v", err)
+		}
+		if s.Name != "gh" || !reflect.DeepEqual(s.Categories, []string{"query"}) || s.Expression != `tags has "read"` {
+			t.Errorf("ParseSpec() = %+v", s)
+		}
+	})
+
+	t.Run("LoadSpec reads from reader", func(t *testing.T) {
+		s, err := LoadSpec(strings.NewReader(mcpSafeSpecYAML))
+		if err != nil {
+			t.Fatalf("LoadSpec() error = %v", err)
+		}
+		if s.Name != "mcp-safe" {
+			t.Errorf("Name = %q", s.Name)
+		}
+	})
+}
+
+func TestParseSpec_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		paths []string
+	}{
+		{"missing name", `categories: [a]`, []string{"name"}},
+		{"unknown field", "name: x\nnamespace: [a]", []string{"namespace"}},
+		{"unknown nested field", "name: x\ntags:\n  some: [a]", []string{"tags.some"}},
+		{"wrong type", "name: x\ncategories: query", []string{"categories"}},
+		{"non-string item", "name: x\ntags:\n  all: [safe, 3]", []string{"tags.all[1]"}},
+		{"empty item", `{"name": "x", "tools": {"deny": ["a", ""]}}`, []string{"tools.deny[1]"}},
+		{"duplicate value", "name: x\npolicy:\n  deny_tags: [a, b, a]", []string{"policy.deny_tags[2]"}},
+		{"bad expression", "name: x\nexpression: 'tags =='", []string{"expression"}},
+		{"multiple errors", "name: 3\nfoo: 1\ntags: []", []string{"name", "foo", "tags"}},
+		{"not a mapping", "- a\n- b", []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpec([]byte(tt.doc))
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ParseSpec() error = %v, want ValidationErrors", err)
+			}
+			var paths []string
+			for _, e := range errs {
+				paths = append(paths, e.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("paths = %q, want %q (%v)", paths, tt.paths, err)
+			}
+		})
+	}
+
+	t.Run("decode errors carry line numbers", func(t *testing.T) {
+		_, err := ParseSpec([]byte("name: x\ntags:\n  all: [safe, 3]"))
+		var errs ValidationErrors
+		errors.As(err, &errs)
+		if errs[0].Line != 3 {
+			t.Errorf("Line = %d, want 3", errs[0].Line)
+		}
+		if !strings.Contains(err.Error(), "tags.all[1] (line 3)") {
+			t.Errorf("Error() = %q", err.Error())
+		}
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		if _, err := ParseSpec([]byte("name: [")); err == nil {
+			t.Error("expected syntax error")
+		}
+		if _, err := ParseSpec(nil); err == nil {
+			t.Error("expected error for empty document")
+		}
+	})
+}
+
+func TestSpec_Builder(t *testing.T) {
+	sources := map[string]Registry{"all": &mockRegistry{tools: journeyTools()}}
+
+	t.Run("compiles filters and policy", func(t *testing.T) {
+		s, err := ParseSpec([]byte(mcpSafeSpecYAML))
+		if err != nil {
+			t.Fatalf("ParseSpec() error = %v", err)
+		}
+		b, err := s.Builder(sources)
+		if err != nil {
+			t.Fatalf("Builder() error = %v", err)
+		}
+		ts, err := b.Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if !reflect.DeepEqual(ts.IDs(), []string{"mcp:search"}) {
+			t.Errorf("IDs() = %v, want [mcp:search]", ts.IDs())
+		}
+		if ts.Name() != "mcp-safe" {
+			t.Errorf("Name() = %q", ts.Name())
+		}
+	})
+
+	t.Run("multiple sources are combined", func(t *testing.T) {
+		s := &Spec{Name: "x", Sources: []string{"all", "extra"}}
+		b, err := s.Builder(map[string]Registry{
+			"all":   sources["all"],
+			"extra": &mockRegistry{tools: []*tooladapter.CanonicalTool{makeTool("jira", "search", nil)}},
+		})
+		if err != nil {
+			t.Fatalf("Builder() error = %v", err)
+		}
+		ts, _ := b.Build()
+		if ts.Count() != 5 {
+			t.Errorf("Count() = %d, want 5", ts.Count())
+		}
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		s := &Spec{Name: "x", Sources: []string{"missing"}}
+		_, err := s.Builder(sources)
+		var errs ValidationErrors
+		if !errors.As(err, &errs) || errs[0].Path != "sources[0]" {
+			t.Errorf("Builder() error = %v, want sources[0] error", err)
+		}
+	})
+
+	t.Run("no sources leaves source to caller", func(t *testing.T) {
+		s := &Spec{Name: "x", Tags: &TagSpec{Any: []string{"danger"}}}
+		b, err := s.Builder(nil)
+		if err != nil {
+			t.Fatalf("Builder() error = %v", err)
+		}
+		ts, err := b.FromTools(journeyTools()).Build()
+		if err != nil {
+			t.Fatalf("Build() error = %v", err)
+		}
+		if !reflect.DeepEqual(ts.IDs(), []string{"mcp:execute"}) {
+			t.Errorf("IDs() = %v", ts.IDs())
+		}
+	})
+
+	t.Run("invalid spec", func(t *testing.T) {
+		if _, err := (&Spec{}).Builder(nil); err == nil {
+			t.Error("expected validation error")
+		}
+	})
+}
+
+func TestBuilder_Spec(t *testing.T) {
+	t.Run("round trips through JSON and YAML", func(t *testing.T) {
+		orig, err := ParseSpec([]byte(mcpSafeSpecYAML))
+		if err != nil {
+			t.Fatalf("ParseSpec() error = %v", err)
+		}
+		orig.Sources = nil // resolved sources are covered separately
+		b, err := orig.Builder(nil)
+		if err != nil {
+			t.Fatalf("Builder() error = %v", err)
+		}
+		got, err := b.Spec()
+		if err != nil {
+			t.Fatalf("Spec() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, orig) {
+			t.Errorf("Spec() = %+v, want %+v", got, orig)
+		}
+
+		data, err := json.Marshal(got)
+		if err != nil {
+			t.Fatalf("json.Marshal() error = %v", err)
+		}
+		fromJSON, err := ParseSpec(data)
+		if err != nil {
+			t.Fatalf("ParseSpec(json) error = %v", err)
+		}
+		if !reflect.DeepEqual(fromJSON, orig) {
+			t.Errorf("JSON round trip = %+v, want %+v", fromJSON, orig)
+		}
+
+		data, err = yaml.Marshal(got)
+		if err != nil {
+			t.Fatalf("yaml.Marshal() error = %v", err)
+		}
+		fromYAML, err := ParseSpec(data)
+		if err != nil {
+			t.Fatalf("ParseSpec(yaml) error = %v\n%s", err, data)
+		}
+		if !reflect.DeepEqual(fromYAML, orig) {
+			t.Errorf("YAML round trip = %+v, want %+v", fromYAML, orig)
+		}
+	})
+
+	t.Run("records labelled sources", func(t *testing.T) {
+		reg := &stubRegistry{}
+		s, err := NewBuilder("x").
+			FromTools(nil).
+			AddSource("primary", 0, reg).
+			AddSource("fallback", 0, reg).
+			Spec()
+		if err != nil {
+			t.Fatalf("Spec() error = %v", err)
+		}
+		if !reflect.DeepEqual(s.Sources, []string{"primary", "fallback"}) {
+			t.Errorf("Sources = %v, want [primary fallback]", s.Sources)
+		}
+
+		if _, err := NewBuilder("x").AddSource("primary", 1, reg).Spec(); err == nil {
+			t.Error("Spec() should reject non-zero source priority")
+		}
+	})
+
+	t.Run("merges repeated filters", func(t *testing.T) {
+		s, err := NewBuilder("x").
+			WithNamespaces([]string{"a", "b", "c"}).
+			WithNamespace("b").
+			WithTags([]string{"read"}).
+			WithTags([]string{"safe", "read"}).
+			ExcludeTools([]string{"b:x"}).
+			ExcludeTools([]string{"b:y"}).
+			WithExpression(`name != "z"`).
+			WithExpression(`tags has "q"`).
+			Spec()
+		if err != nil {
+			t.Fatalf("Spec() error = %v", err)
+		}
+		if !reflect.DeepEqual(s.Namespaces.Include, []string{"b"}) {
+			t.Errorf("Namespaces.Include = %v, want [b]", s.Namespaces.Include)
+		}
+		if !reflect.DeepEqual(s.Tags.All, []string{"read", "safe"}) {
+			t.Errorf("Tags.All = %v", s.Tags.All)
+		}
+		if !reflect.DeepEqual(s.Tools.Deny, []string{"b:x", "b:y"}) {
+			t.Errorf("Tools.Deny = %v", s.Tools.Deny)
+		}
+		if s.Expression != `(name != "z") && (tags has "q")` {
+			t.Errorf("Expression = %q", s.Expression)
+		}
+	})
+
+	t.Run("unrepresentable configuration", func(t *testing.T) {
+		builders := map[string]*Builder{
+			"custom filter":      NewBuilder("x").WithFilter(Always()),
+			"custom policy":      NewBuilder("x").WithPolicy(AllowAll()),
+			"two tags-any":       NewBuilder("x").WithAnyTags([]string{"a"}).WithAnyTags([]string{"b"}),
+			"empty intersection": NewBuilder("x").WithNamespace("a").WithNamespace("b"),
+		}
+		for name, b := range builders {
+			if _, err := b.Spec(); err == nil {
+				t.Errorf("%s: Spec() should fail", name)
+			}
+		}
+	})
+}
+
+func TestPolicySpec_Policy(t *testing.T) {
+	p := PolicySpec{AllowNamespaces: []string{"mcp"}, DenyTags: []string{"danger"}}.Policy()
+	d := Explain(p).Decide(makeTool("mcp", "execute", []string{"danger"}))
+	if d.Allowed() || d.Rule != "deny-tags" {
+		t.Errorf("Decide() = %+v, want deny-tags", d)
+	}
+	if !p.Allow(makeTool("mcp", "search", nil)) {
+		t.Error("expected allow")
+	}
+	if p.Allow(nil) {
+		t.Error("nil tool should be denied")
+	}
+}
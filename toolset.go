@@ -1,8 +1,8 @@
 package toolset
 
 import (
-	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/jonwraymond/tooladapter"
 )
@@ -11,102 +11,103 @@ import (
 type FilterFunc func(*tooladapter.CanonicalTool) bool
 
 // Toolset is a thread-safe collection of canonical tools.
+//
+// Reads are served from an immutable Snapshot published atomically on every
+// mutation, so readers never wait for writers.
 type Toolset struct {
-	name  string
-	mu    sync.RWMutex
-	tools map[string]*tooladapter.CanonicalTool // keyed by ID()
+	name string
+	mu   sync.Mutex // serializes mutations; guards subs and pending
+	snap atomic.Pointer[Snapshot]
+
+	subs      []*subscriber // copy-on-write; guarded by mu
+	pending   []Event       // undelivered events; guarded by mu
+	deliverMu sync.Mutex    // serializes event delivery
+
+	cursorMu  sync.Mutex
+	cursorKey []byte // HMAC key for page cursors; nil uses the process key
 }
 
 // New creates a new Toolset with the given name.
 func New(name string) *Toolset {
-	return &Toolset{
-		name:  name,
-		tools: make(map[string]*tooladapter.CanonicalTool),
-	}
+	return newFromTools(name, nil)
 }
 
 // Name returns the toolset's name.
 func (ts *Toolset) Name() string { return ts.name }
 
-// Add adds a tool. Nil tools are silently ignored.
+// Add adds a tool, replacing any tool with the same ID.
+// Nil tools are silently ignored.
 func (ts *Toolset) Add(tool *tooladapter.CanonicalTool) {
 	if tool == nil {
 		return
 	}
+	id := tool.ID()
 	ts.mu.Lock()
-	defer ts.mu.Unlock()
-	ts.tools[tool.ID()] = tool
+	snap := ts.Snapshot()
+	i, exists := snap.search(id)
+	if exists && snap.tools[i] == tool {
+		ts.mu.Unlock()
+		return
+	}
+	ev := Event{Type: EventAdded, ID: id, New: tool}
+	if exists {
+		ev = Event{Type: EventReplaced, ID: id, Old: snap.tools[i], New: tool}
+	}
+	ts.snap.Store(snap.with(i, tool, exists))
+	queued := ts.enqueue(ev)
+	ts.mu.Unlock()
+	if queued {
+		ts.flush()
+	}
 }
 
 // Get retrieves a tool by ID. Returns (nil, false) if not found.
 func (ts *Toolset) Get(id string) (*tooladapter.CanonicalTool, bool) {
-	ts.mu.RLock()
-	defer ts.mu.RUnlock()
-	tool, ok := ts.tools[id]
-	return tool, ok
+	return ts.Snapshot().Get(id)
 }
 
 // Remove removes a tool by ID. Returns true if found and removed.
 func (ts *Toolset) Remove(id string) bool {
 	ts.mu.Lock()
-	defer ts.mu.Unlock()
-	if _, ok := ts.tools[id]; ok {
-		delete(ts.tools, id)
-		return true
+	snap := ts.Snapshot()
+	i, ok := snap.search(id)
+	if !ok {
+		ts.mu.Unlock()
+		return false
+	}
+	old := snap.tools[i]
+	ts.snap.Store(snap.without(i))
+	queued := ts.enqueue(Event{Type: EventRemoved, ID: id, Old: old})
+	ts.mu.Unlock()
+	if queued {
+		ts.flush()
 	}
-	return false
+	return true
 }
 
 // Count returns the number of tools.
 func (ts *Toolset) Count() int {
-	ts.mu.RLock()
-	defer ts.mu.RUnlock()
-	return len(ts.tools)
+	return ts.Snapshot().Len()
 }
 
 // IDs returns tool IDs sorted lexicographically.
 func (ts *Toolset) IDs() []string {
-	ts.mu.RLock()
-	defer ts.mu.RUnlock()
-	ids := make([]string, 0, len(ts.tools))
-	for id := range ts.tools {
-		ids = append(ids, id)
-	}
-	sort.Strings(ids)
-	return ids
+	return ts.Snapshot().IDs()
 }
 
 // Tools returns all tools sorted lexicographically by ID.
 func (ts *Toolset) Tools() []*tooladapter.CanonicalTool {
-	ts.mu.RLock()
-	defer ts.mu.RUnlock()
-	tools := make([]*tooladapter.CanonicalTool, 0, len(ts.tools))
-	for _, t := range ts.tools {
-		tools = append(tools, t)
-	}
-	sort.Slice(tools, func(i, j int) bool {
-		return tools[i].ID() < tools[j].ID()
-	})
-	return tools
+	return ts.Snapshot().Tools()
 }
 
 // Filter returns a new Toolset with tools matching fn.
 // The original Toolset is not modified.
 func (ts *Toolset) Filter(fn FilterFunc) *Toolset {
-	ts.mu.RLock()
-	// Snapshot matching tools while holding lock
 	var matches []*tooladapter.CanonicalTool
-	for _, t := range ts.tools {
+	for _, t := range ts.Snapshot().tools {
 		if fn(t) {
 			matches = append(matches, t)
 		}
 	}
-	ts.mu.RUnlock()
-
-	// Build new toolset from snapshot (no lock needed)
-	filtered := New(ts.name + "-filtered")
-	for _, t := range matches {
-		filtered.tools[t.ID()] = t
-	}
-	return filtered
+	return newFromTools(ts.name+"-filtered", matches).inheritCursorKey(ts)
 }
@@ -53,6 +53,23 @@ func TestToolset_New(t *testing.T) {
 			t.Errorf("len(IDs()) = %d, want 0", len(ids))
 		}
 	})
+
+	t.Run("zero value is an empty toolset", func(t *testing.T) {
+		var ts Toolset
+		if ts.Count() != 0 || len(ts.IDs()) != 0 || len(ts.Tools()) != 0 || ts.Generation() != 0 {
+			t.Errorf("zero Toolset has Count() = %d, IDs() = %v", ts.Count(), ts.IDs())
+		}
+		if _, ok := ts.Get("ns:a"); ok {
+			t.Error("Get() found a tool in a zero Toolset")
+		}
+		if ts.Remove("ns:a") {
+			t.Error("Remove() = true on a zero Toolset")
+		}
+		ts.Add(makeTool("ns", "a", nil))
+		if ts.Count() != 1 || ts.Generation() != 1 {
+			t.Errorf("after Add: Count() = %d, Generation() = %d", ts.Count(), ts.Generation())
+		}
+	})
 }
 
 func TestToolset_Add(t *testing.T) {